@@ -0,0 +1,99 @@
+package main
+
+import "log"
+
+// Analysis holds artifacts found under config paths and cars dependencies found by config find type.
+// It is the scanning step shared by all commands.
+type Analysis struct {
+	config       *Config
+	fs           FileSystem
+	errors       *FileErrors
+	ArtifactsMap *CarArtifacts
+	CarPaths     map[string]string
+	DepsParser   *DepsParser
+	Deps         *map[string]map[string]*CarDependency
+	findDeps     func(depsParser *DepsParser) *map[string]map[string]*CarDependency
+}
+
+func Analyse(config *Config) *Analysis {
+	a := &Analysis{
+		config: config,
+		fs:     osFileSystem{},
+		errors: NewFileErrors(),
+	}
+	if len(config.Revision) > 0 {
+		a.fs = newGitFileSystem(config.Revision)
+	}
+	artifactParser := NewArtifactParser(a.fs, a.errors, config.Parallelism)
+	switch config.Input {
+	case CarArchivesInput:
+		for _, rootPath := range config.Paths {
+			a.ArtifactsMap = artifactParser.ParseCarArchives(rootPath)
+		}
+		log.Printf("Analysed .car archives")
+		a.findDeps = func(depsParser *DepsParser) *map[string]map[string]*CarDependency {
+			for _, rootPath := range config.Paths {
+				depsParser.findDepsInCarArchives(rootPath)
+			}
+			return &depsParser.deps
+		}
+	case DeploymentInput:
+		// deployments are opened once and shared by artifacts and dependencies parsers
+		var deployments []*Deployment
+		for _, rootPath := range config.Paths {
+			deployment, err := OpenDeployment(rootPath, a.errors)
+			if err != nil {
+				a.errors.Add(rootPath, err)
+				continue
+			}
+			deployments = append(deployments, deployment)
+		}
+		a.ArtifactsMap = artifactParser.carArtifacts()
+		for _, deployment := range deployments {
+			a.ArtifactsMap = artifactParser.ParseDeployment(deployment)
+		}
+		log.Printf("Analysed synapse-configs")
+		a.findDeps = func(depsParser *DepsParser) *map[string]map[string]*CarDependency {
+			for _, deployment := range deployments {
+				depsParser.findDepsInDeployment(deployment)
+			}
+			return &depsParser.deps
+		}
+	default:
+		for _, rootPath := range config.Paths {
+			a.ArtifactsMap = artifactParser.Parse(rootPath)
+		}
+		log.Printf("Analysed artifact.xml files")
+		a.findDeps = func(depsParser *DepsParser) *map[string]map[string]*CarDependency {
+			for _, rootPath := range config.Paths {
+				depsParser.findDeps(rootPath, a.ArtifactsMap)
+			}
+			return &depsParser.deps
+		}
+	}
+	a.CarPaths = artifactParser.CarPaths()
+	a.DepsParser, a.Deps = a.FindDeps(config.FindByRegex)
+	return a
+}
+
+// FindDeps finds cars dependencies once more by given find type
+func (a *Analysis) FindDeps(findByRegex bool) (*DepsParser, *map[string]map[string]*CarDependency) {
+	depsParser := NewDepsParser(a.fs, a.ArtifactsMap, a.config.DirsToSkip, a.config.FilesToSkip, findByRegex, a.errors, a.config.Parallelism)
+	return depsParser, a.findDeps(depsParser)
+}
+
+func (a *Analysis) IsCarAllowed() func(carName string) bool {
+	return createIsCarAllowedFunc(a.config.CarsToAnalyse, a.config.GetIgnoreCarRegex())
+}
+
+// Close releases files kept open by analysed file system, analysis can't find dependencies after it
+func (a *Analysis) Close() {
+	if err := a.fs.Close(); err != nil {
+		log.Printf("Failed to close %s: %s", a.config.Revision, err)
+	}
+}
+
+// Errors returns errors of files not analysed so far
+func (a *Analysis) Errors() []*FileError {
+	return a.errors.List()
+}
@@ -2,8 +2,7 @@ package main
 
 import (
 	"encoding/xml"
-	"io/ioutil"
-	"log"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,72 +11,121 @@ import (
 
 type ArtifactParser struct {
 	sync.Mutex
-	group        sync.WaitGroup
 	artifactsMap map[string][]*Artifact
+	// carPaths are car directories or .car archives
+	carPaths    map[string]string
+	fs          FileSystem
+	errors      *FileErrors
+	parallelism int
 }
 
 func (p *ArtifactParser) Parse(path string) *CarArtifacts {
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() && filepath.Base(path) == "artifact.xml" {
-			go p.parseArtifactXml(path)
-		}
-		return nil
-	})
-	if err != nil {
-		panic(err)
-	}
-	p.group.Wait()
+	runWorkers(p.parallelism, func(artifactXmlPaths chan<- string) {
+		p.fs.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				p.errors.Add(path, err)
+				return nil
+			}
+			if !info.IsDir() && filepath.Base(path) == "artifact.xml" {
+				artifactXmlPaths <- path
+			}
+			return nil
+		})
+	}, p.parseArtifactXml)
+	return p.carArtifacts()
+}
 
+func (p *ArtifactParser) carArtifacts() *CarArtifacts {
 	carArtifacts := make(CarArtifacts)
 	for carName, artifacts := range p.artifactsMap {
-		var artifactNames []string
-		for _, artifact := range artifacts {
-			artifactNames = append(artifactNames, string(artifact.Name))
-		}
-		carArtifacts[string(carName)] = artifactNames
+		carArtifacts[carName] = artifacts
 	}
 	return &carArtifacts
 }
 
-func (p *ArtifactParser) parseArtifactXml(artifactXmlPath string) {
-	p.group.Add(1)
-	defer p.group.Done()
+func (p *ArtifactParser) CarPaths() map[string]string {
+	return p.carPaths
+}
 
+func (p *ArtifactParser) parseArtifactXml(artifactXmlPath string) {
 	artifactXmlPathParts := strings.Split(artifactXmlPath, string(os.PathSeparator))
+	if len(artifactXmlPathParts) < 3 {
+		p.errors.Add(artifactXmlPath, errors.New("artifact.xml is outside of car directory"))
+		return
+	}
 	carName := artifactXmlPathParts[len(artifactXmlPathParts)-3]
 
-	artifactsFromXml := p.getArtifactsFromXml(artifactXmlPath)
+	artifactsFromXml, err := p.getArtifactsFromXml(artifactXmlPath)
+	if err != nil {
+		p.errors.Add(artifactXmlPath, err)
+		return
+	}
+	projectPath := filepath.Dir(artifactXmlPath)
 	for _, artifact := range *artifactsFromXml {
 		if artifact.Item.Path != "" {
-			resourceFolderPath := strings.Replace(artifact.Item.Path, "/_system/governance/", "", 1)
-			resourceFullPath := strings.Join([]string{resourceFolderPath, artifact.Item.File}, "/")
-			artifact.Name = resourceFullPath
+			artifact.Name = resourceArtifactName(artifact.Item)
+			artifact.Path = filepath.Join(projectPath, artifact.Item.File)
+		} else if artifact.File != "" {
+			artifact.Path = filepath.Join(projectPath, artifact.File)
 		}
 	}
 
 	p.Lock()
 	p.artifactsMap[carName] = append(p.artifactsMap[carName], *artifactsFromXml...)
+	if len(p.carPaths[carName]) == 0 {
+		p.carPaths[carName] = filepath.Dir(projectPath)
+	}
 	p.Unlock()
 }
 
-func (p *ArtifactParser) getArtifactsFromXml(path string) *[]*Artifact {
-	xmlFile, err := os.Open(path)
-	if err != nil {
-		log.Fatalln(err)
+func (p *ArtifactParser) ParseCarArchives(path string) *CarArtifacts {
+	walkCarArchives(path, p.errors, func(archive *CarArchive) {
+		p.artifactsMap[archive.Name] = append(p.artifactsMap[archive.Name], archive.Artifacts...)
+		p.carPaths[archive.Name] = archive.Path
+	})
+	return p.carArtifacts()
+}
+
+func (p *ArtifactParser) ParseDeployment(deployment *Deployment) *CarArtifacts {
+	for _, deployed := range deployment.Artifacts {
+		if deployed.FromCarbonApp {
+			continue
+		}
+		p.artifactsMap[deployed.CarName] = append(p.artifactsMap[deployed.CarName], deployed.Artifact)
+		if len(p.carPaths[deployed.CarName]) == 0 {
+			p.carPaths[deployed.CarName] = filepath.Dir(deployed.Path)
+		}
 	}
-	defer xmlFile.Close()
+	if len(deployment.CarbonAppsPath) > 0 {
+		p.ParseCarArchives(deployment.CarbonAppsPath)
+	}
+	return p.carArtifacts()
+}
+
+func resourceArtifactName(item Item) string {
+	resourceFolderPath := strings.Replace(item.Path, "/_system/governance/", "", 1)
+	return strings.Join([]string{resourceFolderPath, item.File}, "/")
+}
 
-	byteValue, _ := ioutil.ReadAll(xmlFile)
+func (p *ArtifactParser) getArtifactsFromXml(path string) (*[]*Artifact, error) {
+	byteValue, err := p.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 	var artifacts Artifacts
 	err = xml.Unmarshal(byteValue, &artifacts)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
 	}
-	return &artifacts.Artifacts
+	return &artifacts.Artifacts, nil
 }
 
-func NewArtifactParser() *ArtifactParser {
+func NewArtifactParser(fileSystem FileSystem, fileErrors *FileErrors, parallelism int) *ArtifactParser {
 	return &ArtifactParser{
 		artifactsMap: make(map[string][]*Artifact),
+		carPaths:     make(map[string]string),
+		fs:           fileSystem,
+		errors:       fileErrors,
+		parallelism:  parallelism,
 	}
 }
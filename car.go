@@ -0,0 +1,181 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const carbonApplicationType = "carbon/application"
+const registryResourceType = "registry/resource"
+
+// CarApplication is the top-level artifacts.xml of a built .car archive
+type CarApplication struct {
+	Artifacts []*CarApplicationArtifact `xml:"artifact"`
+}
+
+type CarApplicationArtifact struct {
+	Name         string                      `xml:"name,attr"`
+	Version      string                      `xml:"version,attr"`
+	Type         string                      `xml:"type,attr"`
+	Dependencies []*CarApplicationDependency `xml:"dependency"`
+}
+
+type CarApplicationDependency struct {
+	Artifact string `xml:"artifact,attr"`
+	Version  string `xml:"version,attr"`
+}
+
+type RegistryInfo struct {
+	Items []Item `xml:"item"`
+}
+
+// CarArchive is an opened .car file with its artifacts read from artifact.xml descriptors
+type CarArchive struct {
+	Name      string
+	Path      string
+	Artifacts []*Artifact
+	// Errors of artifacts skipped because they can't be read
+	Errors []*FileError
+
+	// zip entry of xml payload by artifact name
+	payloads map[string]string
+	files    map[string]*zip.File
+	reader   *zip.ReadCloser
+}
+
+func OpenCarArchive(carPath string) (*CarArchive, error) {
+	reader, err := zip.OpenReader(carPath)
+	if err != nil {
+		return nil, err
+	}
+	archive := &CarArchive{
+		Name:     fileNameWithoutExtension(carPath),
+		Path:     carPath,
+		payloads: map[string]string{},
+		files:    map[string]*zip.File{},
+		reader:   reader,
+	}
+	for _, file := range reader.File {
+		archive.files[file.Name] = file
+	}
+	if err := archive.readArtifacts(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return archive, nil
+}
+
+func (a *CarArchive) Close() error {
+	return a.reader.Close()
+}
+
+func (a *CarArchive) readArtifacts() error {
+	var application CarApplication
+	if err := a.readXml("artifacts.xml", &application); err != nil {
+		return err
+	}
+	for _, appArtifact := range application.Artifacts {
+		if appArtifact.Type != carbonApplicationType {
+			continue
+		}
+		if len(appArtifact.Name) > 0 {
+			a.Name = appArtifact.Name
+		}
+		for _, dependency := range appArtifact.Dependencies {
+			artifactDir := dependency.Artifact + "_" + dependency.Version
+			var artifact Artifact
+			if err := a.readXml(path.Join(artifactDir, "artifact.xml"), &artifact); err != nil {
+				a.Errors = append(a.Errors, &FileError{Path: a.entryPath(path.Join(artifactDir, "artifact.xml")), Err: err})
+				continue
+			}
+			if artifact.Type == registryResourceType {
+				var registryInfo RegistryInfo
+				if err := a.readXml(path.Join(artifactDir, artifact.File), &registryInfo); err != nil {
+					a.Errors = append(a.Errors, &FileError{Path: a.entryPath(path.Join(artifactDir, artifact.File)), Err: err})
+					continue
+				}
+				for _, item := range registryInfo.Items {
+					a.Artifacts = append(a.Artifacts, &Artifact{
+						Name: resourceArtifactName(item),
+						Type: artifact.Type,
+						Item: item,
+						Path: a.entryPath(path.Join(artifactDir, "resources", item.File)),
+					})
+					if path.Ext(item.File) == ".xml" {
+						a.payloads[resourceArtifactName(item)] = path.Join(artifactDir, "resources", item.File)
+					}
+				}
+				continue
+			}
+			artifact.Path = a.entryPath(path.Join(artifactDir, artifact.File))
+			a.Artifacts = append(a.Artifacts, &artifact)
+			if path.Ext(artifact.File) == ".xml" {
+				a.payloads[artifact.Name] = path.Join(artifactDir, artifact.File)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadPayload returns the xml configuration of an artifact, or nil if the archive has none
+func (a *CarArchive) ReadPayload(artifactName string) ([]byte, error) {
+	entryName, ok := a.payloads[artifactName]
+	if !ok {
+		return nil, nil
+	}
+	return a.readFile(entryName)
+}
+
+func (a *CarArchive) entryPath(entryName string) string {
+	return a.Path + "!/" + entryName
+}
+
+func (a *CarArchive) readXml(entryName string, v interface{}) error {
+	content, err := a.readFile(entryName)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(content, v)
+}
+
+func (a *CarArchive) readFile(entryName string) ([]byte, error) {
+	file := a.files[entryName]
+	if file == nil {
+		return nil, os.ErrNotExist
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// walkCarArchives calls fn for every .car archive under rootPath, broken archives are added to fileErrors and skipped
+func walkCarArchives(rootPath string, fileErrors *FileErrors, fn func(archive *CarArchive)) {
+	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			fileErrors.Add(path, err)
+			return nil
+		}
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".car") {
+			return nil
+		}
+		archive, err := OpenCarArchive(path)
+		if err != nil {
+			fileErrors.Add(path, err)
+			return nil
+		}
+		defer archive.Close()
+		for _, fileError := range archive.Errors {
+			fileErrors.Add(fileError.Path, fileError.Err)
+		}
+		fn(archive)
+		return nil
+	})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Command is a subcommand of the tool, all commands share scanning done by Analyse
+type Command struct {
+	name        string
+	description string
+	argsUsage   string
+	// addFlags adds command specific flags to flags shared by all commands
+	addFlags func(f *configFlags)
+	// run executes command with analysis config and positional args, it returns exit code
+	run func(config *Config, args []string) int
+}
+
+const defaultCommand = "graph"
+
+var commands = []*Command{
+	{
+		name:        "graph",
+		description: "render cars dependencies graph and write all reports to outPath",
+		addFlags: func(f *configFlags) {
+			f.stringVar("outPath", f.progPath, "path where result will be saved", func(c *Config, v string) { c.OutPath = v })
+			f.boolVar("renderBothFindTypes", false, "if 'true' then both find types will be rendered", func(c *Config, v bool) { c.RenderBothFindTypes = v })
+			f.boolVar("renderArtifacts", false, "if 'true' then artifacts dependencies graph grouped by car-apps will be rendered too", func(c *Config, v bool) { c.RenderArtifacts = v })
+			f.listVar("outputs", defaultOutputs, "outputs to write", func(c *Config, v []string) { c.Outputs = v })
+			f.stringVar("linkTemplate", "", "url of files in svg nodes, {path} is replaced by file path relative to root path, file urls are used if absent", func(c *Config, v string) { c.LinkTemplate = v })
+			f.listVar("impactOf", nil, "names of changed artifacts or car-apps to find all their dependents", func(c *Config, v []string) { c.ImpactOf = v })
+			addFailOnCyclesFlag(f)
+			f.boolVar("failOnUnresolved", false, "if 'true' then exit code will be 1 when references to missing artifacts are found", func(c *Config, v bool) { c.FailOnUnresolved = v })
+		},
+		run: runGraph,
+	},
+	{
+		name:        "diff",
+		description: "print cars and artifacts dependencies added and removed since old version and render them as diff-graph",
+		argsUsage:   "<old path, graph json or git revision> <new path, graph json or git revision>",
+		addFlags: func(f *configFlags) {
+			f.stringVar("outPath", f.progPath, "path where diff-graph will be saved", func(c *Config, v string) { c.OutPath = v })
+			f.listVar("outputs", []string{"png", "svg", "dot", "txt"}, "outputs to write", func(c *Config, v []string) { c.Outputs = v })
+		},
+		run: runDiff,
+	},
+	{
+		name:        "list",
+		description: "print cars dependencies with artifacts referencing each other",
+		run: func(config *Config, args []string) int {
+			return printToStdout(config, func(w *bufio.Writer, analysis *Analysis) {
+				writeGraph(w, analysis.Deps, config.CarsToAnalyse, config.GetIgnoreCarRegex())
+			})
+		},
+	},
+	{
+		name:        "cycles",
+		description: "print cyclic dependencies of cars",
+		addFlags:    addFailOnCyclesFlag,
+		run: func(config *Config, args []string) int {
+			var cycles [][]string
+			exitCode := printToStdout(config, func(w *bufio.Writer, analysis *Analysis) {
+				cycles = findCarCycles(analysis.Deps, analysis.IsCarAllowed())
+				writeCycles(w, analysis.Deps, cycles)
+			})
+			if config.FailOnCycles && len(cycles) > 0 {
+				return 1
+			}
+			return exitCode
+		},
+	},
+	{
+		name:        "impact",
+		description: "print artifacts depending, directly or transitively, on given artifacts or cars",
+		argsUsage:   "<artifact or car>...",
+		run: func(config *Config, args []string) int {
+			names := config.ImpactOf
+			if len(args) > 0 {
+				names = splitNames(strings.Join(args, ","))
+			}
+			if len(names) == 0 {
+				fmt.Fprintln(os.Stderr, "impact: names of changed artifacts or cars are required")
+				return 2
+			}
+			return printToStdout(config, func(w *bufio.Writer, analysis *Analysis) {
+				writeImpact(w, analysis.Deps, analysis.DepsParser.artifactsToCarMap, names, config.CarsToAnalyse, config.GetIgnoreCarRegex())
+			})
+		},
+	},
+	{
+		name:        "unused",
+		description: "print artifacts not referenced by any other artifact",
+		run: func(config *Config, args []string) int {
+			return printToStdout(config, func(w *bufio.Writer, analysis *Analysis) {
+				writeUnusedArtifacts(w, analysis.ArtifactsMap, analysis.Deps, config.CarsToAnalyse, config.GetIgnoreCarRegex())
+			})
+		},
+	},
+	{
+		name:        "order",
+		description: "print deployment order of cars, dependencies first",
+		run: func(config *Config, args []string) int {
+			return printToStdout(config, func(w *bufio.Writer, analysis *Analysis) {
+				writeDeploymentOrder(w, analysis.Deps, config.CarsToAnalyse, config.GetIgnoreCarRegex())
+			})
+		},
+	},
+}
+
+func findCommand(name string) *Command {
+	for _, command := range commands {
+		if command.name == name {
+			return command
+		}
+	}
+	return nil
+}
+
+func addFailOnCyclesFlag(f *configFlags) {
+	f.boolVar("failOnCycles", false, "if 'true' then exit code will be 1 when cars have cyclic dependencies", func(c *Config, v bool) { c.FailOnCycles = v })
+}
+
+func runGraph(config *Config, args []string) int {
+	result := FindDependencies(config)
+	if (config.FailOnCycles && len(result.Cycles) > 0) || (config.FailOnUnresolved && result.MissingReferences > 0) || (config.Strict && result.Errors > 0) {
+		return 1
+	}
+	return 0
+}
+
+func runDiff(config *Config, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "diff: old and new versions are required")
+		return 2
+	}
+	isCarAllowed := createIsCarAllowedFunc(config.CarsToAnalyse, config.GetIgnoreCarRegex())
+	var dependenciesMaps []*map[string]map[string]*CarDependency
+	var extractionModes []string
+	// both versions of a file failing the same way are reported once
+	allErrors := NewFileErrors()
+	cars := map[string]bool{}
+	for _, path := range args {
+		dependenciesMap, extractionMode, fileErrors, err := loadDependencies(config, path)
+		if err != nil {
+			log.Println(err)
+			return 2
+		}
+		for carName := range *dependenciesMap {
+			if isCarAllowed(carName) {
+				cars[carName] = true
+			}
+		}
+		dependenciesMaps = append(dependenciesMaps, dependenciesMap)
+		extractionModes = append(extractionModes, extractionMode)
+		for _, fileError := range fileErrors {
+			allErrors.Add(fileError.Path, fileError.Err)
+		}
+	}
+	if extractionModes[0] != extractionModes[1] {
+		log.Printf("diff: %s is extracted in %s mode and %s in %s mode, versions have to be extracted the same way", args[0], extractionModes[0], args[1], extractionModes[1])
+		return 2
+	}
+	errorsList := allErrors.List()
+
+	diffs := diffDependencies(dependenciesMaps[0], dependenciesMaps[1], isCarAllowed)
+	w := bufio.NewWriter(os.Stdout)
+	writeDiff(w, diffs)
+	w.Flush()
+	renderDiffGraph(diffs, getSortedMapKeysFromArtifactsPartMap(cars), config.OutPath, config.Outputs)
+	if config.HasOutput("txt") {
+		printDiff(diffs, config.OutPath)
+	}
+
+	logFileErrors(errorsList)
+	if config.Strict && len(errorsList) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printToStdout analyses cars and writes report to stdout, files errors are only logged
+func printToStdout(config *Config, write func(w *bufio.Writer, analysis *Analysis)) int {
+	analysis := Analyse(config)
+	defer analysis.Close()
+	w := bufio.NewWriter(os.Stdout)
+	write(w, analysis)
+	w.Flush()
+
+	errorsList := analysis.Errors()
+	logFileErrors(errorsList)
+	if config.Strict && len(errorsList) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// ConfigFileName is looked up in the project root when no config is given explicitly
+const ConfigFileName = "artifact-deps.json"
+
+// outputs written when config doesn't list them
+var defaultOutputs = []string{"png", "svg", "dot", "txt", "json", "html", "mermaid", "plantuml", "graphml", "gexf", "csv", "tsv", "cycles", "order", "unused", "unresolved", "duplicates"}
+
+// Config holds analysis settings, json keys are the same as command line flags
+type Config struct {
+	Paths               []string `json:"paths"`
+	OutPath             string   `json:"outPath"`
+	CarsToAnalyse       []string `json:"carsToAnalyse"`
+	IgnoreCars          []string `json:"ignoreCars"`
+	IgnoreCarRegex      string   `json:"ignoreCarRegex"`
+	DirsToSkip          []string `json:"dirsToSkip"`
+	FilesToSkip         []string `json:"filesToSkip"`
+	Input               string   `json:"input"`
+	Revision            string   `json:"revision"`
+	FindByRegex         bool     `json:"findByRegex"`
+	RenderBothFindTypes bool     `json:"renderBothFindTypes"`
+	RenderArtifacts     bool     `json:"renderArtifacts"`
+	Outputs             []string `json:"outputs"`
+	LinkTemplate        string   `json:"linkTemplate"`
+	ImpactOf            []string `json:"impactOf"`
+	Parallelism         int      `json:"parallelism"`
+	FailOnCycles        bool     `json:"failOnCycles"`
+	FailOnUnresolved    bool     `json:"failOnUnresolved"`
+	Strict              bool     `json:"strict"`
+}
+
+func NewDefaultConfig(rootPath string) *Config {
+	return &Config{
+		Paths:       []string{rootPath},
+		OutPath:     rootPath,
+		DirsToSkip:  append([]string{}, defaultDirsToSkip...),
+		FilesToSkip: append([]string{}, defaultFilesToSkip...),
+		Input:       SourcesInput,
+		Outputs:     append([]string{}, defaultOutputs...),
+		Parallelism: runtime.GOMAXPROCS(0),
+	}
+}
+
+// LoadConfig overrides config settings present in json file, relative paths are resolved against the file directory
+func LoadConfig(path string, config *Config) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fileConfig := *config
+	fileConfig.Paths = nil
+	fileConfig.OutPath = ""
+	if err := json.Unmarshal(content, &fileConfig); err != nil {
+		return &FileError{Path: path, Err: err}
+	}
+
+	configDir := filepath.Dir(path)
+	resolvePath := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(configDir, p)
+	}
+	if len(fileConfig.Paths) > 0 {
+		for i, p := range fileConfig.Paths {
+			fileConfig.Paths[i] = resolvePath(p)
+		}
+	} else {
+		fileConfig.Paths = config.Paths
+	}
+	if len(fileConfig.OutPath) > 0 {
+		fileConfig.OutPath = resolvePath(fileConfig.OutPath)
+	} else {
+		fileConfig.OutPath = config.OutPath
+	}
+	*config = fileConfig
+	return nil
+}
+
+// findConfigFile returns path of config file in the first of dirs having it, or empty string
+func findConfigFile(dirs ...string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, ConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Validate checks settings which can't be used together
+func (c *Config) Validate() error {
+	switch c.Input {
+	case SourcesInput, CarArchivesInput, DeploymentInput:
+	default:
+		return fmt.Errorf("unknown input %q", c.Input)
+	}
+	if len(c.Revision) > 0 && c.Input != SourcesInput {
+		return fmt.Errorf("revision can be analysed for %s input only", SourcesInput)
+	}
+	for _, output := range c.Outputs {
+		if !isKnownOutput(output) {
+			return fmt.Errorf("unknown output %q, known outputs are %s", output, strings.Join(defaultOutputs, ", "))
+		}
+	}
+	return nil
+}
+
+func isKnownOutput(output string) bool {
+	for _, knownOutput := range defaultOutputs {
+		if knownOutput == output {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) HasOutput(output string) bool {
+	for _, configOutput := range c.Outputs {
+		if configOutput == output {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIgnoreCarRegex joins ignored car names and ignore regex into one regex
+func (c *Config) GetIgnoreCarRegex() string {
+	var regexParts []string
+	if len(c.IgnoreCars) > 0 {
+		quotedNames := make([]string, len(c.IgnoreCars))
+		for i, carName := range c.IgnoreCars {
+			quotedNames[i] = regexp.QuoteMeta(carName)
+		}
+		regexParts = append(regexParts, "^(?:"+strings.Join(quotedNames, "|")+")$")
+	}
+	if len(c.IgnoreCarRegex) > 0 {
+		regexParts = append(regexParts, c.IgnoreCarRegex)
+	}
+	return strings.Join(regexParts, "|")
+}
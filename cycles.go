@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// findCarCycles returns strongly connected components of the car graph having more than one car.
+// Cars of every cycle are sorted, cycles are sorted by their first car.
+func findCarCycles(dependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool) [][]string {
+	var cycles [][]string
+	for _, component := range findCarComponents(dependenciesMap, isCarAllowed) {
+		if len(component) > 1 {
+			cycles = append(cycles, component)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+// findCarComponents returns all strongly connected components of the car graph with sorted cars.
+// Components are returned in reverse topological order, dependencies go before dependent cars.
+func findCarComponents(dependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool) [][]string {
+	index := 0
+	indexes := map[string]int{}
+	lowLinks := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var components [][]string
+
+	var strongConnect func(car string)
+	strongConnect = func(car string) {
+		indexes[car] = index
+		lowLinks[car] = index
+		index++
+		stack = append(stack, car)
+		onStack[car] = true
+
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[car]) {
+			dependency := (*dependenciesMap)[car][carTo]
+			if carTo == car || !dependency.HaveDependency || !isCarAllowed(carTo) {
+				continue
+			}
+			if _, visited := indexes[carTo]; !visited {
+				strongConnect(carTo)
+				if lowLinks[carTo] < lowLinks[car] {
+					lowLinks[car] = lowLinks[carTo]
+				}
+			} else if onStack[carTo] && indexes[carTo] < lowLinks[car] {
+				lowLinks[car] = indexes[carTo]
+			}
+		}
+
+		if lowLinks[car] == indexes[car] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == car {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, car := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		if _, visited := indexes[car]; !visited && isCarAllowed(car) {
+			strongConnect(car)
+		}
+	}
+	return components
+}
+
+// getCycleEdges returns set of car edges lying inside one of cycles
+func getCycleEdges(dependenciesMap *map[string]map[string]*CarDependency, cycles [][]string) map[string]map[string]bool {
+	cycleEdges := map[string]map[string]bool{}
+	for _, cycle := range cycles {
+		inCycle := map[string]bool{}
+		for _, car := range cycle {
+			inCycle[car] = true
+		}
+		for _, carFrom := range cycle {
+			for carTo, dependency := range (*dependenciesMap)[carFrom] {
+				if carFrom != carTo && dependency.HaveDependency && inCycle[carTo] {
+					if cycleEdges[carFrom] == nil {
+						cycleEdges[carFrom] = map[string]bool{}
+					}
+					cycleEdges[carFrom][carTo] = true
+				}
+			}
+		}
+	}
+	return cycleEdges
+}
+
+func printCycles(dependenciesMap *map[string]map[string]*CarDependency, cycles [][]string, outPath string, fileNamePrefix string) {
+	f, err := os.Create(filepath.Join(outPath, fileNamePrefix+"cycles.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	writeCycles(w, dependenciesMap, cycles)
+
+	w.Flush()
+}
+
+func writeCycles(w *bufio.Writer, dependenciesMap *map[string]map[string]*CarDependency, cycles [][]string) {
+	cycleEdges := getCycleEdges(dependenciesMap, cycles)
+	for _, cycle := range cycles {
+		w.WriteString("cycle: " + strings.Join(cycle, ", ") + "\n")
+		for _, carFrom := range cycle {
+			for _, carTo := range cycle {
+				if !cycleEdges[carFrom][carTo] {
+					continue
+				}
+				dependency := (*dependenciesMap)[carFrom][carTo]
+				w.WriteString("  " + carFrom + " -> " + carTo + "\n")
+				fromArtifactLen := calcMaxFromArtifactDepLen(dependency)
+				for _, fromArtifact := range getSortedMapKeysFromArtifactFullMap(dependency.ArtifactDependencies) {
+					for _, toArtifact := range getSortedMapKeysFromArtifactsPartMap(dependency.ArtifactDependencies[fromArtifact]) {
+						padding := strings.Repeat(" ", fromArtifactLen-len(fromArtifact))
+						w.WriteString("    " + fromArtifact + padding + " -> " + toArtifact + "\n")
+					}
+				}
+			}
+		}
+		w.WriteString("\n")
+	}
+}
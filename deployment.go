@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// artifact types of synapse-configs sub directories
+var synapseConfigDirTypes = map[string]string{
+	"api":                "synapse/api",
+	"endpoints":          "synapse/endpoint",
+	"inbound-endpoints":  "synapse/inbound-endpoint",
+	"local-entries":      "synapse/local-entry",
+	"message-processors": "synapse/message-processors",
+	"message-stores":     "synapse/message-store",
+	"proxy-services":     "synapse/proxy-service",
+	"sequences":          "synapse/sequence",
+	"tasks":              "synapse/task",
+	"templates":          "synapse/template",
+}
+
+// DeployedArtifact is an artifact xml file found in synapse-configs of a deployed server
+type DeployedArtifact struct {
+	CarName  string
+	Artifact *Artifact
+	Path     string
+	// FromCarbonApp is set when artifact belongs to a .car of carbonapps, which is parsed instead of the file
+	FromCarbonApp bool
+}
+
+// Deployment is the synapse configuration of a deployed server.
+// Artifacts deployed from carbon-apps are grouped by car name, the rest by synapse-configs directory.
+type Deployment struct {
+	ConfigPath     string
+	CarbonAppsPath string
+	Artifacts      []*DeployedArtifact
+}
+
+type synapseConfigRoot struct {
+	Name string `xml:"name,attr"`
+	Key  string `xml:"key,attr"`
+}
+
+// OpenDeployment finds synapse-configs under rootPath, files which can't be read are added to fileErrors and skipped
+func OpenDeployment(rootPath string, fileErrors *FileErrors) (*Deployment, error) {
+	configPath := findSynapseConfigDir(rootPath)
+	if len(configPath) == 0 {
+		return nil, fmt.Errorf("synapse-configs not found in %s", rootPath)
+	}
+	deployment := &Deployment{
+		ConfigPath:     configPath,
+		CarbonAppsPath: findCarbonAppsDir(rootPath, configPath),
+	}
+
+	artifactsToCar := map[string]string{}
+	if len(deployment.CarbonAppsPath) > 0 {
+		walkCarArchives(deployment.CarbonAppsPath, fileErrors, func(archive *CarArchive) {
+			for _, artifact := range archive.Artifacts {
+				artifactsToCar[artifact.Name] = archive.Name
+			}
+		})
+	}
+
+	for dirName, artifactType := range synapseConfigDirTypes {
+		files, err := filepath.Glob(filepath.Join(configPath, dirName, "*.xml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			artifactName, err := readSynapseConfigName(file)
+			if err != nil {
+				fileErrors.Add(file, err)
+				continue
+			}
+			carName, fromCarbonApp := artifactsToCar[artifactName]
+			if !fromCarbonApp {
+				carName = dirName
+			}
+			deployment.Artifacts = append(deployment.Artifacts, &DeployedArtifact{
+				CarName:       carName,
+				Artifact:      &Artifact{Name: artifactName, Type: artifactType, Path: file},
+				Path:          file,
+				FromCarbonApp: fromCarbonApp,
+			})
+		}
+	}
+	return deployment, nil
+}
+
+func readSynapseConfigName(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var root synapseConfigRoot
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return "", err
+	}
+	if len(root.Name) > 0 {
+		return root.Name, nil
+	}
+	if len(root.Key) > 0 {
+		return root.Key, nil
+	}
+	return fileNameWithoutExtension(path), nil
+}
+
+func findSynapseConfigDir(rootPath string) string {
+	candidates := []string{
+		rootPath,
+		filepath.Join(rootPath, "default"),
+		filepath.Join(rootPath, "synapse-configs", "default"),
+		filepath.Join(rootPath, "repository", "deployment", "server", "synapse-configs", "default"),
+	}
+	for _, candidate := range candidates {
+		for dirName := range synapseConfigDirTypes {
+			if info, err := os.Stat(filepath.Join(candidate, dirName)); err == nil && info.IsDir() {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+func findCarbonAppsDir(rootPath string, configPath string) string {
+	candidates := []string{
+		filepath.Join(rootPath, "repository", "deployment", "server", "carbonapps"),
+		filepath.Join(configPath, "..", "..", "carbonapps"),
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return filepath.Clean(candidate)
+		}
+	}
+	return ""
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
 	"github.com/beevik/etree"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -17,26 +22,53 @@ var defaultFilesToSkip = []string{"pom.xml", "artifact.xml"}
 type DepsParser struct {
 	deps              map[string]map[string]*CarDependency
 	artifactsToCarMap map[string]string
+	artifacts         map[string]*Artifact
 	artifactsRegex    *regexp.Regexp
 	dirsToSkip        []string
 	filesToSkip       []string
 	findByRegex       bool
+	fs                FileSystem
 
-	parseEsbXml func(dp *DepsParser, path string, curFileCarName string)
+	// findArtifacts returns found references and names which may be not an artifact reference
+	findArtifacts func(dp *DepsParser, content []byte) ([]string, map[string]bool, error)
+
+	unresolved  map[string]*UnresolvedReference
+	errors      *FileErrors
+	parallelism int
 
 	sync.Mutex
-	group sync.WaitGroup
 }
 
-func NewDepsParser(artifactsMap *CarArtifacts, dirsToSkip []string, filesToSkip []string, findByRegex bool) *DepsParser {
+func NewDepsParser(fileSystem FileSystem, artifactsMap *CarArtifacts, dirsToSkip []string, filesToSkip []string, findByRegex bool, fileErrors *FileErrors, parallelism int) *DepsParser {
 	var artifactsToCarMap = make(map[string]string)
+	var artifacts = make(map[string]*Artifact)
 	var allArtifacts []string
-	for carName, artifactNames := range *artifactsMap {
-		for _, artifactName := range artifactNames {
-			artifactsToCarMap[artifactName] = carName
-			allArtifacts = append(allArtifacts, regexp.QuoteMeta(string(artifactName)))
+	// artifact defined in several cars is attributed to the first car by name
+	carNames := make([]string, 0, len(*artifactsMap))
+	for carName := range *artifactsMap {
+		carNames = append(carNames, carName)
+	}
+	sort.Strings(carNames)
+	for _, carName := range carNames {
+		for _, artifact := range (*artifactsMap)[carName] {
+			if len(artifactsToCarMap[artifact.Name]) > 0 {
+				continue
+			}
+			artifactsToCarMap[artifact.Name] = carName
+			artifacts[artifact.Name] = artifact
+			allArtifacts = append(allArtifacts, artifact.Name)
 		}
 	}
+	// longer names go first, so regex matches whole name instead of its prefix
+	sort.Slice(allArtifacts, func(i, j int) bool {
+		if len(allArtifacts[i]) != len(allArtifacts[j]) {
+			return len(allArtifacts[i]) > len(allArtifacts[j])
+		}
+		return allArtifacts[i] < allArtifacts[j]
+	})
+	for i, artifactName := range allArtifacts {
+		allArtifacts[i] = regexp.QuoteMeta(artifactName)
+	}
 
 	var allArtifactsRegexStr = strings.Join(allArtifacts, "|")
 	var allArtifactsRegex = regexp.MustCompile(allArtifactsRegexStr)
@@ -46,54 +78,133 @@ func NewDepsParser(artifactsMap *CarArtifacts, dirsToSkip []string, filesToSkip
 		deps[carName] = map[string]*CarDependency{}
 	}
 
-	parseEsbXmlFunc := parseEsbXmlByMarshalling
+	findArtifactsFunc := findArtifactsByMarshalling
 	if findByRegex {
-		parseEsbXmlFunc = parseEsbXmlByRegex
+		findArtifactsFunc = findArtifactsByRegex
 	}
 
 	return &DepsParser{
 		deps:              deps,
 		artifactsToCarMap: artifactsToCarMap,
+		artifacts:         artifacts,
 		artifactsRegex:    allArtifactsRegex,
 		dirsToSkip:        dirsToSkip,
 		filesToSkip:       filesToSkip,
-		parseEsbXml:       parseEsbXmlFunc,
+		findArtifacts:     findArtifactsFunc,
 		findByRegex:       findByRegex,
+		fs:                fileSystem,
+		unresolved:        map[string]*UnresolvedReference{},
+		errors:            fileErrors,
+		parallelism:       parallelism,
 	}
 }
 
-func FindDependencies(rootPath string, outPath string, carsToAnalyse []string, ignoreCarRegex string, findByRegex bool, renderBothFindTypes bool) {
-	artifactsMap := NewArtifactParser().Parse(rootPath)
-	log.Printf("Analysed artifact.xml files")
-	depsParser := NewDepsParser(artifactsMap, defaultDirsToSkip, defaultFilesToSkip, findByRegex)
-	carDependenciesMap := depsParser.findDeps(rootPath, artifactsMap)
-	renderGraph(carDependenciesMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
-	printGraph(carDependenciesMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
-	if renderBothFindTypes {
+const (
+	SourcesInput     = "sources"
+	CarArchivesInput = "cars"
+	DeploymentInput  = "deployment"
+)
+
+// FindDependencies analyses cars under config paths and saves results to config outPath
+func FindDependencies(config *Config) *AnalysisResult {
+	outPath := config.OutPath
+	carsToAnalyse := config.CarsToAnalyse
+	ignoreCarRegex := config.GetIgnoreCarRegex()
+	analysis := Analyse(config)
+	defer analysis.Close()
+	artifactsMap := analysis.ArtifactsMap
+
+	if config.HasOutput("duplicates") {
+		printDuplicateArtifacts(artifactsMap, outPath, carsToAnalyse, ignoreCarRegex)
+	}
+	depsParser := analysis.DepsParser
+	carDependenciesMap := analysis.Deps
+	writeGraphOutputs := func(depsParser *DepsParser, carDependenciesMap *map[string]map[string]*CarDependency) {
+		links := newGraphLinks(analysis.CarPaths, depsParser.artifacts, config.Paths, config.LinkTemplate)
+		renderGraph(carDependenciesMap, links, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix(), config.Outputs)
+		printCarsDiagrams(carDependenciesMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix(), config.Outputs)
+		if config.RenderArtifacts {
+			renderArtifactsGraph(carDependenciesMap, artifactsMap, depsParser.artifacts, links, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix(), config.Outputs)
+			printArtifactsDiagrams(carDependenciesMap, artifactsMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix(), config.Outputs)
+		}
+		if config.HasOutput("txt") {
+			printGraph(carDependenciesMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
+		}
+		if config.HasOutput("json") {
+			printJsonGraph(carDependenciesMap, depsParser.artifacts, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getFindType())
+		}
+		if config.HasOutput("html") {
+			printHtmlReport(carDependenciesMap, depsParser.artifacts, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getFindType())
+		}
+		if config.HasOutput("csv") || config.HasOutput("tsv") {
+			printArtifactEdges(carDependenciesMap, depsParser.artifacts, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getFindType(), config.Outputs)
+		}
+		if config.HasOutput("graphml") || config.HasOutput("gexf") {
+			printNetworkGraphs(carDependenciesMap, artifactsMap, depsParser.artifacts, analysis.CarPaths, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getFindType(), config.Outputs)
+		}
+	}
+	writeGraphOutputs(depsParser, carDependenciesMap)
+	isCarAllowed := analysis.IsCarAllowed()
+	cycles := findCarCycles(carDependenciesMap, isCarAllowed)
+	if config.HasOutput("cycles") {
+		printCycles(carDependenciesMap, cycles, outPath, depsParser.getTypePrefix())
+	}
+	if config.HasOutput("order") {
+		printDeploymentOrder(carDependenciesMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
+	}
+	if config.HasOutput("unused") {
+		printUnusedArtifacts(artifactsMap, carDependenciesMap, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
+	}
+	unresolvedReferences := depsParser.getUnresolvedReferences(isCarAllowed)
+	if config.FindByRegex && (config.FailOnUnresolved || config.HasOutput("unresolved")) {
+		log.Printf("Unresolved references are found in xml mode only, regex finds names of existing artifacts")
+	}
+	if config.HasOutput("unresolved") {
+		printUnresolvedReferences(unresolvedReferences, outPath, depsParser.getTypePrefix())
+	}
+	missingReferences := countMissingReferences(unresolvedReferences)
+	if missingReferences > 0 {
+		log.Printf("Found %d references to missing artifacts", missingReferences)
+	}
+	if len(config.ImpactOf) > 0 {
+		printImpact(carDependenciesMap, depsParser.artifactsToCarMap, config.ImpactOf, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
+	}
+	for _, cycle := range cycles {
+		log.Printf("Found cycle: %s", strings.Join(cycle, ", "))
+	}
+	if config.RenderBothFindTypes {
 		var carDependenciesByRegex *map[string]map[string]*CarDependency
 		var carDependenciesByMarshalling *map[string]map[string]*CarDependency
-		if findByRegex {
+		var otherDepsParser *DepsParser
+		if config.FindByRegex {
 			carDependenciesByRegex = carDependenciesMap
-			depsParser := NewDepsParser(artifactsMap, defaultDirsToSkip, defaultFilesToSkip, !findByRegex)
-			carDependenciesByMarshalling = depsParser.findDeps(rootPath, artifactsMap)
-			renderGraph(carDependenciesByMarshalling, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
-			printGraph(carDependenciesByMarshalling, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
+			otherDepsParser, carDependenciesByMarshalling = analysis.FindDeps(false)
+			writeGraphOutputs(otherDepsParser, carDependenciesByMarshalling)
 		} else {
 			carDependenciesByMarshalling = carDependenciesMap
-			depsParser := NewDepsParser(artifactsMap, defaultDirsToSkip, defaultFilesToSkip, !findByRegex)
-			carDependenciesByRegex = depsParser.findDeps(rootPath, artifactsMap)
-			renderGraph(carDependenciesByRegex, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
-			printGraph(carDependenciesByRegex, outPath, carsToAnalyse, ignoreCarRegex, depsParser.getTypePrefix())
+			otherDepsParser, carDependenciesByRegex = analysis.FindDeps(true)
+			writeGraphOutputs(otherDepsParser, carDependenciesByRegex)
 		}
-		renderBothTypesGraph(carDependenciesByRegex, carDependenciesByMarshalling, outPath, carsToAnalyse, ignoreCarRegex)
+		renderBothTypesGraph(carDependenciesByRegex, carDependenciesByMarshalling, outPath, carsToAnalyse, ignoreCarRegex, config.Outputs)
+	}
+	errorsList := analysis.Errors()
+	printFileErrors(errorsList, outPath)
+	return &AnalysisResult{
+		Cycles:            cycles,
+		MissingReferences: missingReferences,
+		Errors:            len(errorsList),
 	}
 }
 
 func (d *DepsParser) getTypePrefix() string {
+	return d.getFindType() + "-"
+}
+
+func (d *DepsParser) getFindType() string {
 	if d.findByRegex {
-		return "regex-"
+		return "regex"
 	} else {
-		return "xml-"
+		return "xml"
 	}
 }
 
@@ -104,74 +215,165 @@ func (d *DepsParser) findDeps(path string, artifactsMap *CarArtifacts) *map[stri
 	}
 	fileCounter := 0
 
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
-			if d.isSkipDir(info) {
-				return filepath.SkipDir
-			}
-		} else {
-			if d.isSkipFile(path, info) {
+	runWorkers(d.parallelism, func(xmlPaths chan<- string) {
+		d.fs.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				d.errors.Add(path, err)
 				return nil
 			}
-			// process file
-			carName := getCarName(path)
-			if len(carName) == 0 {
-				return nil
+			if info.IsDir() {
+				if d.isSkipDir(info) {
+					return filepath.SkipDir
+				}
+			} else {
+				if d.isSkipFile(path, info) {
+					return nil
+				}
+				// process file
+				if len(getCarName(path)) == 0 {
+					return nil
+				}
+				xmlPaths <- path
+				fileCounter++
+				log.Printf("started %d file analyses", fileCounter)
 			}
-			go d.parseEsbXml(d, path, string(carName))
-			fileCounter++
-			log.Printf("started %d file analyses", fileCounter)
-		}
-		return nil
+			return nil
+		})
+	}, func(path string) {
+		d.parseEsbXml(path, getCarName(path))
 	})
-	if err != nil {
-		panic(err)
-	}
-	d.group.Wait()
 	return &d.deps
 }
 
-func parseEsbXmlByMarshalling(dp *DepsParser, path string, curFileCarName string) {
-	dp.group.Add(1)
-	defer dp.group.Done()
+func (d *DepsParser) findDepsInCarArchives(path string) *map[string]map[string]*CarDependency {
+	walkCarArchives(path, d.errors, func(archive *CarArchive) {
+		for _, artifact := range archive.Artifacts {
+			content, err := archive.ReadPayload(artifact.Name)
+			if err != nil {
+				d.errors.Add(artifact.Path, err)
+				continue
+			}
+			if content == nil {
+				continue
+			}
+			foundArtifacts, optionalArtifacts, err := d.findArtifacts(d, content)
+			if err != nil {
+				d.errors.Add(artifact.Path, err)
+				continue
+			}
+			d.addCarDependencies(foundArtifacts, optionalArtifacts, archive.Name, artifact.Name, artifact.Path)
+		}
+		log.Printf("analysed %s", archive.Path)
+	})
+	return &d.deps
+}
 
-	doc := etree.NewDocument()
-	if err := doc.ReadFromFile(path); err != nil {
-		panic(err)
+func (d *DepsParser) findDepsInDeployment(deployment *Deployment) *map[string]map[string]*CarDependency {
+	deployedByPath := map[string]*DeployedArtifact{}
+	for _, deployed := range deployment.Artifacts {
+		if !deployed.FromCarbonApp {
+			deployedByPath[deployed.Path] = deployed
+		}
 	}
-	foundArtifacts := FindArtifactsInDoc(doc)
-
-	dp.addCarDependencies(foundArtifacts, curFileCarName, path)
+	runWorkers(d.parallelism, func(xmlPaths chan<- string) {
+		for _, deployed := range deployment.Artifacts {
+			if !deployed.FromCarbonApp {
+				xmlPaths <- deployed.Path
+			}
+		}
+	}, func(path string) {
+		deployed := deployedByPath[path]
+		content, err := ioutil.ReadFile(deployed.Path)
+		if err != nil {
+			d.errors.Add(deployed.Path, err)
+			return
+		}
+		foundArtifacts, optionalArtifacts, err := d.findArtifacts(d, content)
+		if err != nil {
+			d.errors.Add(deployed.Path, err)
+			return
+		}
+		d.addCarDependencies(foundArtifacts, optionalArtifacts, deployed.CarName, deployed.Artifact.Name, deployed.Path)
+	})
+	if len(deployment.CarbonAppsPath) > 0 {
+		d.findDepsInCarArchives(deployment.CarbonAppsPath)
+	}
+	return &d.deps
 }
 
-func parseEsbXmlByRegex(dp *DepsParser, path string, curFileCarName string) {
-	dp.group.Add(1)
-	defer dp.group.Done()
-
-	xmlFile, err := os.Open(path)
+func (d *DepsParser) parseEsbXml(path string, curFileCarName string) {
+	textBytes, err := d.fs.ReadFile(path)
 	if err != nil {
-		panic(err)
+		d.errors.Add(path, err)
+		return
 	}
-	defer xmlFile.Close()
 
-	textBytes, err := ioutil.ReadAll(xmlFile)
+	foundArtifacts, optionalArtifacts, err := d.findArtifacts(d, textBytes)
 	if err != nil {
-		panic(err)
+		d.errors.Add(path, err)
+		return
 	}
-	text := string(textBytes)
+	d.addCarDependencies(foundArtifacts, optionalArtifacts, curFileCarName, fileNameWithoutExtension(path), path)
+}
 
-	foundArtifactsDeps := dp.artifactsRegex.FindAllString(text, -1)
-	dp.addCarDependencies(foundArtifactsDeps, curFileCarName, path)
+func findArtifactsByMarshalling(dp *DepsParser, content []byte) ([]string, map[string]bool, error) {
+	if err := checkWellFormed(content); err != nil {
+		return nil, nil, err
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(content); err != nil {
+		return nil, nil, err
+	}
+	if doc.Root() == nil {
+		return nil, nil, errors.New("no root element")
+	}
+	return FindArtifactsInDoc(doc), FindGetPropertyNames(doc), nil
 }
 
-func (d *DepsParser) addCarDependencies(foundArtifactsDeps []string, curFileCarName string, fromPath string) {
+// checkWellFormed reads all tokens of content, etree doesn't check that end tags match start tags
+func checkWellFormed(content []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	// encodings are not converted, as by etree
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func findArtifactsByRegex(dp *DepsParser, content []byte) ([]string, map[string]bool, error) {
+	return dp.artifactsRegex.FindAllString(string(content), -1), nil, nil
+}
+
+func (d *DepsParser) addCarDependencies(foundArtifactsDeps []string, optionalArtifacts map[string]bool, curFileCarName string, fromArtifact string, fromPath string) {
 	d.Lock()
 	defer d.Unlock()
-	fromArtifact := string(fileNameWithoutExtension(fromPath))
+	if d.artifacts[fromArtifact] == nil {
+		d.artifacts[fromArtifact] = &Artifact{Name: fromArtifact}
+	}
+	if len(d.artifacts[fromArtifact].Path) == 0 {
+		d.artifacts[fromArtifact].Path = fromPath
+	}
 	for _, toArtifactStr := range foundArtifactsDeps {
 		toArtifact := string(toArtifactStr)
+		if isDynamicKey(toArtifact) {
+			continue
+		}
 		toCarName := d.artifactsToCarMap[toArtifact]
-		if len(toCarName) > 0 && curFileCarName != toCarName {
+		if len(toCarName) == 0 && !optionalArtifacts[toArtifact] {
+			d.addUnresolvedReference(toArtifact, curFileCarName, fromArtifact, fromPath)
+		}
+		// dependencies inside one car are kept for artifact level analysis, car level outputs skip them
+		if len(toCarName) > 0 && fromArtifact != toArtifact {
+			if d.deps[curFileCarName] == nil {
+				d.deps[curFileCarName] = map[string]*CarDependency{}
+			}
 			if d.deps[curFileCarName][toCarName] == nil {
 				d.deps[curFileCarName][toCarName] = NewCarDependency()
 			}
@@ -200,8 +402,8 @@ func getCarName(path string) string {
 }
 
 func (d *DepsParser) isSkipFile(path string, info os.FileInfo) bool {
-	for _, file := range d.filesToSkip {
-		if info.Name() == file {
+	for _, filePattern := range d.filesToSkip {
+		if matched, _ := filepath.Match(filePattern, info.Name()); matched {
 			return true
 		}
 	}
@@ -212,8 +414,8 @@ func (d *DepsParser) isSkipFile(path string, info os.FileInfo) bool {
 }
 
 func (d *DepsParser) isSkipDir(info os.FileInfo) bool {
-	for _, dir := range d.dirsToSkip {
-		if info.Name() == dir {
+	for _, dirPattern := range d.dirsToSkip {
+		if matched, _ := filepath.Match(dirPattern, info.Name()); matched {
 			return true
 		}
 	}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+// newTestDepsParser parses nothing, dependencies are added by addCarDependencies as if found in files
+func newTestDepsParser(artifactsMap CarArtifacts) *DepsParser {
+	return NewDepsParser(osFileSystem{}, &artifactsMap, nil, nil, false, NewFileErrors(), 1)
+}
+
+func TestCarOutputsSkipSelfEdges(t *testing.T) {
+	d := newTestDepsParser(CarArtifacts{
+		"CarA": {{Name: "SeqA"}, {Name: "EpA"}},
+		"CarB": {{Name: "SeqB"}},
+	})
+	d.addCarDependencies([]string{"EpA", "SeqB", "SeqA"}, nil, "CarA", "SeqA", "CarA/SeqA.xml")
+	if !d.deps["CarA"]["CarA"].ArtifactDependencies["SeqA"]["EpA"] {
+		t.Fatalf("dependency inside CarA is not kept")
+	}
+	if d.deps["CarA"]["CarA"].ArtifactDependencies["SeqA"]["SeqA"] {
+		t.Errorf("artifact depends on itself")
+	}
+	isCarAllowed := createIsCarAllowedFunc(nil, "")
+
+	var graph bytes.Buffer
+	w := bufio.NewWriter(&graph)
+	writeGraph(w, &d.deps, nil, "")
+	w.Flush()
+	if expected := "CarA -> CarB\n  SeqA -> SeqB\n\nCarB\n\n"; graph.String() != expected {
+		t.Errorf("graph.txt is %q, expected %q", graph.String(), expected)
+	}
+	if cycles := findCarCycles(&d.deps, isCarAllowed); len(cycles) > 0 {
+		t.Errorf("self-edge makes cycles %v", cycles)
+	}
+	if order := getDeploymentOrder(&d.deps, isCarAllowed); len(order) != 2 {
+		t.Errorf("deployment order is %v", order)
+	}
+	jsonGraph := NewJsonGraph(&d.deps, d.artifacts, nil, "", "xml")
+	if dependencies := jsonGraph.Cars[0].Dependencies; len(dependencies) != 1 || dependencies[0].Car != "CarB" {
+		t.Errorf("json dependencies of CarA are %v", dependencies)
+	}
+	if edges := newCarsDiagram(&d.deps, isCarAllowed).edges; len(edges) != 1 || edges[0].to.label != "CarB" {
+		t.Errorf("cars diagram has %d edges", len(edges))
+	}
+	if edges := newCarsNetwork(&d.deps, nil, isCarAllowed, "xml").edges; len(edges) != 1 || edges[0].to.label != "CarB" {
+		t.Errorf("cars network has %d edges", len(edges))
+	}
+
+	// artifact edges keep dependencies inside one car
+	var rows bytes.Buffer
+	csvWriter := csv.NewWriter(&rows)
+	writeArtifactEdges(csvWriter, &d.deps, d.artifacts, nil, "", "xml")
+	csvWriter.Flush()
+	if !strings.Contains(rows.String(), "CarA,SeqA,,CarA/SeqA.xml,CarA,EpA,,xml\n") {
+		t.Errorf("artifact edges have no dependency inside CarA:\n%s", rows.String())
+	}
+}
+
+func TestDynamicKeysAreNotUnresolved(t *testing.T) {
+	d := newTestDepsParser(CarArtifacts{"CarA": {{Name: "SeqA"}}})
+	content := []byte(`<sequence name="SeqA">
+		<sequence key="{get-property('dyn')}"/>
+		<send><endpoint key="{$ctx:endpointName}"/></send>
+		<store messageStore="{$ctx:storeName}"/>
+		<call-template target="Missing"/>
+	</sequence>`)
+	foundArtifacts, optionalArtifacts, err := findArtifactsByMarshalling(d, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.addCarDependencies(foundArtifacts, optionalArtifacts, "CarA", "SeqA", "CarA/SeqA.xml")
+	var references []string
+	for _, reference := range d.getUnresolvedReferences(createIsCarAllowedFunc(nil, "")) {
+		references = append(references, reference.Reference)
+	}
+	if strings.Join(references, ",") != "Missing" {
+		t.Errorf("unresolved references are %v", references)
+	}
+}
+
+func TestFindArtifactsInMalformedXml(t *testing.T) {
+	d := newTestDepsParser(CarArtifacts{"CarA": {{Name: "SeqA"}}})
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"well formed", `<sequence name="S"><sequence key="SeqA"/></sequence>`, true},
+		{"latin-1", `<?xml version="1.0" encoding="ISO-8859-1"?><sequence name="S"/>`, true},
+		{"mismatched end tag", `<sequence name="S"><send><endpoint key="EpA"/></call></sequence>`, false},
+		{"unclosed element", `<sequence name="S"><send>`, false},
+		{"no root", ``, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := findArtifactsByMarshalling(d, []byte(test.content))
+			if test.valid && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+			if !test.valid && err == nil {
+				t.Errorf("malformed xml parsed")
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// diagram is a graph written as text for markdown wikis, nodes of artifacts diagram are grouped by car.
+// Nodes and edges are sorted the same way as in graph.txt, so diagrams don't change between runs.
+type diagram struct {
+	nodes  []*diagramNode
+	groups []*diagramGroup
+	edges  []*diagramEdge
+}
+
+type diagramNode struct {
+	id    string
+	label string
+}
+
+type diagramGroup struct {
+	id    string
+	label string
+	nodes []*diagramNode
+}
+
+type diagramEdge struct {
+	from *diagramNode
+	to   *diagramNode
+	// cycle edges are red as in rendered graph
+	cycle bool
+}
+
+func newCarsDiagram(dependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool) *diagram {
+	cycleEdges := getCycleEdges(dependenciesMap, findCarCycles(dependenciesMap, isCarAllowed))
+	d := &diagram{}
+	nodeMap := map[string]*diagramNode{}
+	for _, carName := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		if isCarAllowed(carName) {
+			nodeMap[carName] = &diagramNode{id: "car" + strconv.Itoa(len(d.nodes)), label: carName}
+			d.nodes = append(d.nodes, nodeMap[carName])
+		}
+	}
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			if carFrom != carTo && dependency.HaveDependency && nodeMap[carFrom] != nil && nodeMap[carTo] != nil {
+				d.edges = append(d.edges, &diagramEdge{from: nodeMap[carFrom], to: nodeMap[carTo], cycle: cycleEdges[carFrom][carTo]})
+			}
+		}
+	}
+	return d
+}
+
+// getCarArtifactNames returns names of artifacts of every car, either defined in car or taking part in its dependencies
+func getCarArtifactNames(dependenciesMap *map[string]map[string]*CarDependency, artifactsMap *CarArtifacts) map[string]map[string]bool {
+	carArtifacts := map[string]map[string]bool{}
+	addArtifact := func(carName string, artifactName string) {
+		if carArtifacts[carName] == nil {
+			carArtifacts[carName] = map[string]bool{}
+		}
+		carArtifacts[carName][artifactName] = true
+	}
+	for carName, artifacts := range *artifactsMap {
+		for _, artifact := range artifacts {
+			addArtifact(carName, artifact.Name)
+		}
+	}
+	for carFrom, depToCars := range *dependenciesMap {
+		for carTo, dependency := range depToCars {
+			for fromArtifact, toArtifacts := range dependency.ArtifactDependencies {
+				addArtifact(carFrom, fromArtifact)
+				for toArtifact := range toArtifacts {
+					addArtifact(carTo, toArtifact)
+				}
+			}
+		}
+	}
+	return carArtifacts
+}
+
+// newArtifactsDiagram has every artifact of allowed cars as renderArtifactsGraph does
+func newArtifactsDiagram(dependenciesMap *map[string]map[string]*CarDependency, artifactsMap *CarArtifacts, isCarAllowed func(carName string) bool) *diagram {
+	carArtifacts := getCarArtifactNames(dependenciesMap, artifactsMap)
+	d := &diagram{}
+	nodeMap := map[string]map[string]*diagramNode{}
+	nodesCount := 0
+	for _, carName := range getSortedMapKeysFromArtifactFullMap(carArtifacts) {
+		if !isCarAllowed(carName) {
+			continue
+		}
+		group := &diagramGroup{id: "car" + strconv.Itoa(len(d.groups)), label: carName}
+		nodeMap[carName] = map[string]*diagramNode{}
+		for _, artifactName := range getSortedMapKeysFromArtifactsPartMap(carArtifacts[carName]) {
+			node := &diagramNode{id: "artifact" + strconv.Itoa(nodesCount), label: artifactName}
+			nodesCount++
+			nodeMap[carName][artifactName] = node
+			group.nodes = append(group.nodes, node)
+		}
+		d.groups = append(d.groups, group)
+	}
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			for _, fromArtifact := range getSortedMapKeysFromArtifactFullMap(dependency.ArtifactDependencies) {
+				for _, toArtifact := range getSortedMapKeysFromArtifactsPartMap(dependency.ArtifactDependencies[fromArtifact]) {
+					fromNode, toNode := nodeMap[carFrom][fromArtifact], nodeMap[carTo][toArtifact]
+					if fromNode != nil && toNode != nil {
+						d.edges = append(d.edges, &diagramEdge{from: fromNode, to: toNode})
+					}
+				}
+			}
+		}
+	}
+	return d
+}
+
+func writeMermaid(w *bufio.Writer, d *diagram) {
+	label := func(text string) string {
+		return "[\"" + strings.Replace(text, "\"", "#quot;", -1) + "\"]"
+	}
+
+	w.WriteString("flowchart LR\n")
+	for _, node := range d.nodes {
+		w.WriteString("  " + node.id + label(node.label) + "\n")
+	}
+	for _, group := range d.groups {
+		w.WriteString("  subgraph " + group.id + label(group.label) + "\n")
+		for _, node := range group.nodes {
+			w.WriteString("    " + node.id + label(node.label) + "\n")
+		}
+		w.WriteString("  end\n")
+	}
+	var cycleEdges []string
+	for i, edge := range d.edges {
+		w.WriteString("  " + edge.from.id + " --> " + edge.to.id + "\n")
+		if edge.cycle {
+			cycleEdges = append(cycleEdges, strconv.Itoa(i))
+		}
+	}
+	if len(cycleEdges) > 0 {
+		w.WriteString("  linkStyle " + strings.Join(cycleEdges, ",") + " stroke:red\n")
+	}
+}
+
+func writePlantUml(w *bufio.Writer, d *diagram) {
+	// plantuml has no escaping of quotes in names
+	quote := func(text string) string {
+		return "\"" + strings.Replace(text, "\"", "'", -1) + "\""
+	}
+
+	w.WriteString("@startuml\n")
+	for _, node := range d.nodes {
+		w.WriteString("component " + quote(node.label) + " as " + node.id + "\n")
+	}
+	for _, group := range d.groups {
+		w.WriteString("package " + quote(group.label) + " {\n")
+		for _, node := range group.nodes {
+			w.WriteString("  component " + quote(node.label) + " as " + node.id + "\n")
+		}
+		w.WriteString("}\n")
+	}
+	for _, edge := range d.edges {
+		arrow := " --> "
+		if edge.cycle {
+			arrow = " -[#red]-> "
+		}
+		w.WriteString(edge.from.id + arrow + edge.to.id + "\n")
+	}
+	w.WriteString("@enduml\n")
+}
+
+// printDiagrams writes diagram to basePath with extension of every diagram format listed in outputs
+func printDiagrams(d *diagram, basePath string, outputs []string) {
+	printDiagram := func(path string, write func(w *bufio.Writer, d *diagram)) {
+		f, err := os.Create(path)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w := bufio.NewWriter(f)
+		write(w, d)
+		w.Flush()
+	}
+	for _, output := range outputs {
+		switch output {
+		case "mermaid":
+			printDiagram(basePath+".mmd", writeMermaid)
+		case "plantuml":
+			printDiagram(basePath+".puml", writePlantUml)
+		}
+	}
+}
+
+func printCarsDiagrams(dependenciesMap *map[string]map[string]*CarDependency, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string, outputs []string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	printDiagrams(newCarsDiagram(dependenciesMap, isCarAllowed), filepath.Join(outPath, fileNamePrefix+"graph"), outputs)
+}
+
+func printArtifactsDiagrams(dependenciesMap *map[string]map[string]*CarDependency, artifactsMap *CarArtifacts, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string, outputs []string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	printDiagrams(newArtifactsDiagram(dependenciesMap, artifactsMap, isCarAllowed), filepath.Join(outPath, fileNamePrefix+"artifacts-graph"), outputs)
+}
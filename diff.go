@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/goccy/go-graphviz"
+	"github.com/goccy/go-graphviz/cgraph"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type DependencyChange int
+
+const (
+	Unchanged DependencyChange = iota
+	Added
+	Removed
+)
+
+func (c DependencyChange) sign() string {
+	switch c {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return " "
+}
+
+type ArtifactDependencyDiff struct {
+	From   string
+	To     string
+	Change DependencyChange
+}
+
+// CarDependencyDiff is a dependency of one car on another in old or new graph,
+// ArtifactDependencies has only added and removed artifact dependencies
+type CarDependencyDiff struct {
+	CarFrom              string
+	CarTo                string
+	Change               DependencyChange
+	ArtifactDependencies []*ArtifactDependencyDiff
+}
+
+func (d *CarDependencyDiff) countChanges(change DependencyChange) int {
+	count := 0
+	for _, artifactDependency := range d.ArtifactDependencies {
+		if artifactDependency.Change == change {
+			count++
+		}
+	}
+	return count
+}
+
+// diffDependencies compares dependencies between allowed cars, result is sorted by cars names
+func diffDependencies(oldDependenciesMap *map[string]map[string]*CarDependency, newDependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool) []*CarDependencyDiff {
+	getArtifactDependencies := func(dependenciesMap *map[string]map[string]*CarDependency, carFrom string, carTo string) map[string]map[string]bool {
+		dependency := (*dependenciesMap)[carFrom][carTo]
+		if carFrom == carTo || dependency == nil || !dependency.HaveDependency {
+			return nil
+		}
+		return dependency.ArtifactDependencies
+	}
+
+	carPairs := map[string]map[string]bool{}
+	for _, dependenciesMap := range []*map[string]map[string]*CarDependency{oldDependenciesMap, newDependenciesMap} {
+		for carFrom, depToCars := range *dependenciesMap {
+			for carTo := range depToCars {
+				if !isCarAllowed(carFrom) || !isCarAllowed(carTo) || getArtifactDependencies(dependenciesMap, carFrom, carTo) == nil {
+					continue
+				}
+				if carPairs[carFrom] == nil {
+					carPairs[carFrom] = map[string]bool{}
+				}
+				carPairs[carFrom][carTo] = true
+			}
+		}
+	}
+
+	var diffs []*CarDependencyDiff
+	for _, carFrom := range getSortedMapKeysFromArtifactFullMap(carPairs) {
+		for _, carTo := range getSortedMapKeysFromArtifactsPartMap(carPairs[carFrom]) {
+			oldArtifactDependencies := getArtifactDependencies(oldDependenciesMap, carFrom, carTo)
+			newArtifactDependencies := getArtifactDependencies(newDependenciesMap, carFrom, carTo)
+			diff := &CarDependencyDiff{CarFrom: carFrom, CarTo: carTo}
+			if oldArtifactDependencies == nil {
+				diff.Change = Added
+			} else if newArtifactDependencies == nil {
+				diff.Change = Removed
+			}
+			diff.ArtifactDependencies = append(diffArtifactDependencies(oldArtifactDependencies, newArtifactDependencies, Removed),
+				diffArtifactDependencies(newArtifactDependencies, oldArtifactDependencies, Added)...)
+			sortArtifactDependencyDiffs(diff.ArtifactDependencies)
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+// diffArtifactDependencies returns dependencies present in artifactDependencies and absent in otherArtifactDependencies
+func diffArtifactDependencies(artifactDependencies map[string]map[string]bool, otherArtifactDependencies map[string]map[string]bool, change DependencyChange) []*ArtifactDependencyDiff {
+	var diffs []*ArtifactDependencyDiff
+	for fromArtifact, toArtifacts := range artifactDependencies {
+		for toArtifact := range toArtifacts {
+			if !otherArtifactDependencies[fromArtifact][toArtifact] {
+				diffs = append(diffs, &ArtifactDependencyDiff{From: fromArtifact, To: toArtifact, Change: change})
+			}
+		}
+	}
+	return diffs
+}
+
+func sortArtifactDependencyDiffs(diffs []*ArtifactDependencyDiff) {
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].From != diffs[j].From {
+			return diffs[i].From < diffs[j].From
+		}
+		return diffs[i].To < diffs[j].To
+	})
+}
+
+func writeDiff(w *bufio.Writer, diffs []*CarDependencyDiff) {
+	for _, diff := range diffs {
+		if diff.Change == Unchanged && len(diff.ArtifactDependencies) == 0 {
+			continue
+		}
+		w.WriteString(diff.Change.sign() + " " + diff.CarFrom + " -> " + diff.CarTo + "\n")
+		fromArtifactLen := 0
+		for _, artifactDependency := range diff.ArtifactDependencies {
+			if fromArtifactLen < len(artifactDependency.From) {
+				fromArtifactLen = len(artifactDependency.From)
+			}
+		}
+		for _, artifactDependency := range diff.ArtifactDependencies {
+			padding := strings.Repeat(" ", fromArtifactLen-len(artifactDependency.From))
+			w.WriteString("  " + artifactDependency.Change.sign() + " " + artifactDependency.From + padding + " -> " + artifactDependency.To + "\n")
+		}
+		w.WriteString("\n")
+	}
+}
+
+// renderDiffGraph draws dependencies of both graphs, added edges are green, removed ones are red,
+// edges with changed artifact dependencies are labeled with count of added and removed ones
+func renderDiffGraph(diffs []*CarDependencyDiff, carNames []string, outPath string, outputs []string) {
+	g := graphviz.New()
+	graph, err := g.Graph()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := graph.Close(); err != nil {
+			log.Fatal(err)
+		}
+		g.Close()
+	}()
+
+	nodeMap := map[string]*cgraph.Node{}
+	appendNodeToGraph := func(carName string) {
+		if nodeMap[carName] == nil {
+			n1, err := graph.CreateNode(string(carName))
+			if err != nil {
+				panic(err)
+			}
+			nodeMap[carName] = n1
+		}
+	}
+	for _, carName := range carNames {
+		appendNodeToGraph(carName)
+	}
+
+	for _, diff := range diffs {
+		appendNodeToGraph(diff.CarFrom)
+		appendNodeToGraph(diff.CarTo)
+		edge, err := graph.CreateEdge("", nodeMap[diff.CarFrom], nodeMap[diff.CarTo])
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch diff.Change {
+		case Added:
+			edge.SetColor("green")
+		case Removed:
+			edge.SetColor("red")
+		default:
+			if len(diff.ArtifactDependencies) > 0 {
+				edge.SetLabel("+" + strconv.Itoa(diff.countChanges(Added)) + " -" + strconv.Itoa(diff.countChanges(Removed)))
+			}
+		}
+	}
+
+	renderGraphFiles(g, graph, filepath.Join(outPath, "diff-graph"), outputs)
+}
+
+func printDiff(diffs []*CarDependencyDiff, outPath string) {
+	f, err := os.Create(filepath.Join(outPath, "diff.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	writeDiff(w, diffs)
+
+	w.Flush()
+}
+
+// loadDependencies reads dependencies saved by printJsonGraph or analyses cars under version path with config settings.
+// Version which is neither json nor existing path is a git revision of config paths.
+// Extraction mode of dependencies is returned too, versions found in different modes can't be compared.
+func loadDependencies(config *Config, version string) (*map[string]map[string]*CarDependency, string, []*FileError, error) {
+	if strings.EqualFold(filepath.Ext(version), ".json") {
+		jsonGraph, err := readJsonGraph(version)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return jsonGraph.Dependencies(), jsonGraph.ExtractionMode, nil, nil
+	}
+	versionConfig := *config
+	if _, err := os.Stat(version); err == nil {
+		versionConfig.Paths = []string{version}
+	} else {
+		versionConfig.Revision = version
+		if err := versionConfig.Validate(); err != nil {
+			return nil, "", nil, err
+		}
+	}
+	analysis := Analyse(&versionConfig)
+	defer analysis.Close()
+	return analysis.Deps, analysis.DepsParser.getFindType(), analysis.Errors(), nil
+}
+
+// readJsonGraph reads graph saved by printJsonGraph
+func readJsonGraph(path string) (*JsonGraph, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jsonGraph JsonGraph
+	if err := json.Unmarshal(content, &jsonGraph); err != nil {
+		return nil, &FileError{Path: path, Err: err}
+	}
+	if jsonGraph.SchemaVersion != JsonSchemaVersion {
+		return nil, &FileError{Path: path, Err: fmt.Errorf("unsupported schema version %d", jsonGraph.SchemaVersion)}
+	}
+	return &jsonGraph, nil
+}
+
+// Dependencies returns cars dependencies saved in json graph
+func (g *JsonGraph) Dependencies() *map[string]map[string]*CarDependency {
+	dependenciesMap := map[string]map[string]*CarDependency{}
+	for _, jsonCar := range g.Cars {
+		if dependenciesMap[jsonCar.Name] == nil {
+			dependenciesMap[jsonCar.Name] = map[string]*CarDependency{}
+		}
+		for _, jsonDependency := range jsonCar.Dependencies {
+			dependency := NewCarDependency()
+			for _, artifactDependency := range jsonDependency.ArtifactDependencies {
+				if dependency.ArtifactDependencies[artifactDependency.From.Name] == nil {
+					dependency.ArtifactDependencies[artifactDependency.From.Name] = map[string]bool{}
+				}
+				dependency.ArtifactDependencies[artifactDependency.From.Name][artifactDependency.To.Name] = true
+			}
+			dependenciesMap[jsonCar.Name][jsonDependency.Car] = dependency
+		}
+	}
+	return &dependenciesMap
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// findDuplicateArtifacts returns artifacts defined in more than one allowed car.
+// Definitions of every artifact are sorted by car, the first one is used in dependencies.
+func findDuplicateArtifacts(artifactsMap *CarArtifacts, isCarAllowed func(carName string) bool) map[string][]*CarArtifact {
+	definitions := map[string][]*CarArtifact{}
+	for carName, artifacts := range *artifactsMap {
+		if !isCarAllowed(carName) {
+			continue
+		}
+		for _, artifact := range artifacts {
+			definitions[artifact.Name] = append(definitions[artifact.Name], &CarArtifact{Car: carName, Artifact: artifact})
+		}
+	}
+
+	duplicates := map[string][]*CarArtifact{}
+	for artifactName, carArtifacts := range definitions {
+		sort.Slice(carArtifacts, func(i, j int) bool {
+			if carArtifacts[i].Car != carArtifacts[j].Car {
+				return carArtifacts[i].Car < carArtifacts[j].Car
+			}
+			return carArtifacts[i].Artifact.Path < carArtifacts[j].Artifact.Path
+		})
+		if carArtifacts[0].Car != carArtifacts[len(carArtifacts)-1].Car {
+			duplicates[artifactName] = carArtifacts
+		}
+	}
+	return duplicates
+}
+
+func printDuplicateArtifacts(artifactsMap *CarArtifacts, outPath string, carNames []string, ignoreCarRegex string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	duplicates := findDuplicateArtifacts(artifactsMap, isCarAllowed)
+
+	f, err := os.Create(filepath.Join(outPath, "duplicates.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	artifactNames := make([]string, 0, len(duplicates))
+	for artifactName := range duplicates {
+		artifactNames = append(artifactNames, artifactName)
+	}
+	sort.Strings(artifactNames)
+	for _, artifactName := range artifactNames {
+		log.Printf("Artifact %s is defined in several cars", artifactName)
+		w.WriteString(artifactName + "\n")
+		for _, carArtifact := range duplicates[artifactName] {
+			w.WriteString("  " + carArtifact.Car + " " + carArtifact.Artifact.Path + "\n")
+		}
+		w.WriteString("\n")
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+)
+
+var artifactEdgesHeader = []string{"sourceCar", "sourceArtifact", "sourceType", "sourceFile", "targetCar", "targetArtifact", "targetType", "extractionMode"}
+
+// writeArtifactEdges writes a row for every artifact dependency, including dependencies inside one car
+// which graph.txt skips, rows are sorted by source car and target car as in graph.txt
+func writeArtifactEdges(w *csv.Writer, dependenciesMap *map[string]map[string]*CarDependency, artifacts map[string]*Artifact, carNames []string, ignoreCarRegex string, findType string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	artifactTypeAndFile := func(name string) (string, string) {
+		if artifact := artifacts[name]; artifact != nil {
+			return artifact.Type, artifact.Path
+		}
+		return "", ""
+	}
+
+	w.Write(artifactEdgesHeader)
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		if !isCarAllowed(carFrom) {
+			continue
+		}
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			if !isCarAllowed(carTo) {
+				continue
+			}
+			for _, fromArtifact := range getSortedMapKeysFromArtifactFullMap(dependency.ArtifactDependencies) {
+				fromType, fromFile := artifactTypeAndFile(fromArtifact)
+				for _, toArtifact := range getSortedMapKeysFromArtifactsPartMap(dependency.ArtifactDependencies[fromArtifact]) {
+					toType, _ := artifactTypeAndFile(toArtifact)
+					w.Write([]string{carFrom, fromArtifact, fromType, fromFile, carTo, toArtifact, toType, findType})
+				}
+			}
+		}
+	}
+}
+
+// printArtifactEdges writes artifact-edges.csv and artifact-edges.tsv for every of these formats listed in outputs
+func printArtifactEdges(dependenciesMap *map[string]map[string]*CarDependency, artifacts map[string]*Artifact, outPath string, carNames []string, ignoreCarRegex string, findType string, outputs []string) {
+	printEdges := func(path string, comma rune) {
+		f, err := os.Create(path)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w := csv.NewWriter(f)
+		w.Comma = comma
+		writeArtifactEdges(w, dependenciesMap, artifacts, carNames, ignoreCarRegex, findType)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			panic(err)
+		}
+	}
+	for _, output := range outputs {
+		switch output {
+		case "csv":
+			printEdges(filepath.Join(outPath, findType+"-artifact-edges.csv"), ',')
+		case "tsv":
+			printEdges(filepath.Join(outPath, findType+"-artifact-edges.tsv"), '\t')
+		}
+	}
+}
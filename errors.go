@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileError is an error of analysing one file, the analysis continues without it
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// FileErrors collects errors of all analysed files, the same error of a file is kept once
+type FileErrors struct {
+	sync.Mutex
+	errors map[string]*FileError
+}
+
+func NewFileErrors() *FileErrors {
+	return &FileErrors{
+		errors: map[string]*FileError{},
+	}
+}
+
+func (e *FileErrors) Add(path string, err error) {
+	e.Lock()
+	defer e.Unlock()
+	fileError := &FileError{Path: path, Err: err}
+	e.errors[fileError.Error()] = fileError
+}
+
+// List returns errors sorted by path
+func (e *FileErrors) List() []*FileError {
+	e.Lock()
+	defer e.Unlock()
+	fileErrors := make([]*FileError, 0, len(e.errors))
+	for _, fileError := range e.errors {
+		fileErrors = append(fileErrors, fileError)
+	}
+	sort.Slice(fileErrors, func(i, j int) bool {
+		return fileErrors[i].Error() < fileErrors[j].Error()
+	})
+	return fileErrors
+}
+
+func printFileErrors(fileErrors []*FileError, outPath string) {
+	f, err := os.Create(filepath.Join(outPath, "errors.txt"))
+	if err != nil {
+		log.Printf("can't write errors: %s", err)
+		return
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	for _, fileError := range fileErrors {
+		log.Printf("Error: %s", fileError)
+		w.WriteString(fileError.Error() + "\n")
+	}
+	if len(fileErrors) > 0 {
+		log.Printf("%d files were not analysed because of errors, see errors.txt", len(fileErrors))
+	}
+
+	w.Flush()
+}
+
+func logFileErrors(fileErrors []*FileError) {
+	for _, fileError := range fileErrors {
+		log.Printf("Error: %s", fileError)
+	}
+	if len(fileErrors) > 0 {
+		log.Printf("%d files were not analysed because of errors", len(fileErrors))
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSystem is a tree of analysed files, either files of work tree or files of a git revision
+type FileSystem interface {
+	Walk(root string, walkFn filepath.WalkFunc) error
+	ReadFile(path string) ([]byte, error)
+	Close() error
+}
+
+type osFileSystem struct{}
+
+func (osFileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
+	return filepath.Walk(root, walkFn)
+}
+
+func (osFileSystem) ReadFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+func (osFileSystem) Close() error {
+	return nil
+}
+
+// gitFileSystem reads files of a revision of git repositories containing analysed paths.
+// Paths are the same as of work tree files, so outputs look as if the revision was checked out.
+type gitFileSystem struct {
+	revision string
+	sync.Mutex
+	trees map[string]*gitRevisionTree
+}
+
+type gitRevisionTree struct {
+	repo     *GitRepository
+	treeHash string
+}
+
+func newGitFileSystem(revision string) *gitFileSystem {
+	return &gitFileSystem{
+		revision: revision,
+		trees:    map[string]*gitRevisionTree{},
+	}
+}
+
+// revisionTree returns root tree of the revision of repository containing path and path relative to its work tree
+func (fs *gitFileSystem) revisionTree(path string) (*gitRevisionTree, string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", err
+	}
+	fs.Lock()
+	defer fs.Unlock()
+	for workTree, tree := range fs.trees {
+		if relPath, err := filepath.Rel(workTree, absPath); err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) {
+			return tree, relPath, nil
+		}
+	}
+
+	repo, err := OpenGitRepository(absPath)
+	if err != nil {
+		return nil, "", err
+	}
+	commitHash, err := repo.ResolveRevision(fs.revision)
+	if err != nil {
+		repo.Close()
+		return nil, "", err
+	}
+	treeHash, err := repo.CommitTree(commitHash)
+	if err != nil {
+		repo.Close()
+		return nil, "", err
+	}
+	tree := &gitRevisionTree{repo: repo, treeHash: treeHash}
+	fs.trees[repo.WorkTree] = tree
+	relPath, err := filepath.Rel(repo.WorkTree, absPath)
+	return tree, relPath, err
+}
+
+// findEntry returns tree entry at path relative to work tree, trees on the way are cached by repository
+func (t *gitRevisionTree) findEntry(relPath string) (*gitTreeEntry, error) {
+	entry := &gitTreeEntry{mode: "40000", hash: t.treeHash}
+	if relPath == "." {
+		return entry, nil
+	}
+	for _, name := range strings.Split(relPath, string(os.PathSeparator)) {
+		if !entry.isDir() {
+			return nil, os.ErrNotExist
+		}
+		entries, err := t.repo.readTree(entry.hash)
+		if err != nil {
+			return nil, err
+		}
+		entry = nil
+		for _, child := range entries {
+			if child.name == name {
+				entry = child
+				break
+			}
+		}
+		if entry == nil {
+			return nil, os.ErrNotExist
+		}
+	}
+	return entry, nil
+}
+
+// Walk walks revision tree in lexical order as filepath.Walk does, symlinks and submodules are skipped
+func (fs *gitFileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
+	tree, relPath, err := fs.revisionTree(root)
+	if err != nil {
+		return walkFn(root, nil, err)
+	}
+	entry, err := tree.findEntry(relPath)
+	if err != nil {
+		return walkFn(root, nil, &os.PathError{Op: "walk", Path: root, Err: err})
+	}
+	err = fs.walk(tree, root, &gitFileInfo{name: filepath.Base(root), entry: entry}, walkFn)
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func (fs *gitFileSystem) walk(tree *gitRevisionTree, path string, info *gitFileInfo, walkFn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return walkFn(path, info, nil)
+	}
+	entries, err := tree.repo.readTree(info.entry.hash)
+	err1 := walkFn(path, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+
+	// entries are cached by repository, so they are sorted in a copy
+	entries = append([]*gitTreeEntry(nil), entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	for _, entry := range entries {
+		if !entry.isDir() && !entry.isFile() {
+			continue
+		}
+		err := fs.walk(tree, filepath.Join(path, entry.name), &gitFileInfo{name: entry.name, entry: entry}, walkFn)
+		if err != nil {
+			if !entry.isDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (fs *gitFileSystem) ReadFile(path string) ([]byte, error) {
+	tree, relPath, err := fs.revisionTree(path)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := tree.findEntry(relPath)
+	if err == nil && !entry.isFile() {
+		err = fmt.Errorf("not a file at %s", fs.revision)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "read", Path: path, Err: err}
+	}
+	return tree.repo.readBlob(entry.hash)
+}
+
+// Close closes repositories opened by file system
+func (fs *gitFileSystem) Close() error {
+	fs.Lock()
+	defer fs.Unlock()
+	var firstErr error
+	for _, tree := range fs.trees {
+		if err := tree.repo.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// gitFileInfo describes tree entry, size of files is unknown until their blob is read
+type gitFileInfo struct {
+	name  string
+	entry *gitTreeEntry
+}
+
+func (i *gitFileInfo) Name() string {
+	return i.name
+}
+
+func (i *gitFileInfo) Size() int64 {
+	return 0
+}
+
+func (i *gitFileInfo) Mode() os.FileMode {
+	if i.IsDir() {
+		return os.ModeDir | 0755
+	}
+	if i.entry.mode == "100755" {
+		return 0755
+	}
+	return 0644
+}
+
+func (i *gitFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i *gitFileInfo) IsDir() bool {
+	return i.entry.isDir()
+}
+
+func (i *gitFileInfo) Sys() interface{} {
+	return nil
+}
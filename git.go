@@ -0,0 +1,658 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GitRepository reads objects of a local git repository, loose and packed ones, without git installed
+type GitRepository struct {
+	WorkTree string
+	gitDir   string
+	// commonDir holds objects and refs, it differs from gitDir in linked work trees
+	commonDir string
+	packs     []*gitPack
+
+	// trees are cached as files of every directory are looked up from root tree
+	treesLock sync.Mutex
+	trees     map[string][]*gitTreeEntry
+}
+
+type gitObjectType int
+
+const (
+	gitCommit   gitObjectType = 1
+	gitTree     gitObjectType = 2
+	gitBlob     gitObjectType = 3
+	gitTag      gitObjectType = 4
+	gitOfsDelta gitObjectType = 6
+	gitRefDelta gitObjectType = 7
+)
+
+var gitObjectTypes = map[string]gitObjectType{
+	"commit": gitCommit,
+	"tree":   gitTree,
+	"blob":   gitBlob,
+	"tag":    gitTag,
+}
+
+type gitTreeEntry struct {
+	name string
+	mode string
+	hash string
+}
+
+func (e *gitTreeEntry) isDir() bool {
+	return e.mode == "40000"
+}
+
+// isFile is false for symlinks and submodules, they are not analysed
+func (e *gitTreeEntry) isFile() bool {
+	return strings.HasPrefix(e.mode, "100")
+}
+
+// OpenGitRepository opens repository containing path, its .git is looked up in path and its parents
+func OpenGitRepository(path string) (*GitRepository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for dir := absPath; ; dir = filepath.Dir(dir) {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				// work trees and submodules have .git file pointing to git dir
+				if gitDir, err = readGitDirLink(dotGit); err != nil {
+					return nil, err
+				}
+			}
+			return openGitDir(dir, gitDir)
+		}
+		if filepath.Dir(dir) == dir {
+			return nil, fmt.Errorf("%s is not inside git repository", path)
+		}
+	}
+}
+
+func readGitDirLink(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", &FileError{Path: path, Err: errors.New("no gitdir")}
+	}
+	gitDir := strings.TrimPrefix(line, "gitdir: ")
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return gitDir, nil
+}
+
+func openGitDir(workTree string, gitDir string) (*GitRepository, error) {
+	repo := &GitRepository{WorkTree: workTree, gitDir: gitDir, commonDir: gitDir, trees: map[string][]*gitTreeEntry{}}
+	if content, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(content))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		repo.commonDir = commonDir
+	}
+
+	idxPaths, err := filepath.Glob(filepath.Join(repo.commonDir, "objects", "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	for _, idxPath := range idxPaths {
+		pack, err := openGitPack(idxPath)
+		if err != nil {
+			repo.Close()
+			return nil, err
+		}
+		repo.packs = append(repo.packs, pack)
+	}
+	return repo, nil
+}
+
+// Close closes pack files of repository
+func (r *GitRepository) Close() error {
+	var firstErr error
+	for _, pack := range r.packs {
+		if err := pack.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ResolveRevision returns hash of commit given by revision: a ref, a tag, a branch or an abbreviated hash,
+// optionally followed by ~n and ^n suffixes
+func (r *GitRepository) ResolveRevision(revision string) (string, error) {
+	name := revision
+	var suffixes []string
+	if i := strings.IndexAny(revision, "~^"); i > 0 {
+		name, suffixes = revision[:i], splitRevisionSuffixes(revision[i:])
+	}
+
+	hash, err := r.resolveName(name)
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s: %s", revision, err)
+	}
+	if hash, err = r.peelToCommit(hash); err != nil {
+		return "", err
+	}
+	for _, suffix := range suffixes {
+		n := 1
+		if len(suffix) > 1 {
+			if n, err = strconv.Atoi(suffix[1:]); err != nil {
+				return "", fmt.Errorf("unknown revision %s", revision)
+			}
+		}
+		switch suffix[0] {
+		case '~':
+			for i := 0; i < n; i++ {
+				if hash, err = r.commitParent(hash, 1); err != nil {
+					return "", fmt.Errorf("unknown revision %s: %s", revision, err)
+				}
+			}
+		case '^':
+			if n > 0 {
+				if hash, err = r.commitParent(hash, n); err != nil {
+					return "", fmt.Errorf("unknown revision %s: %s", revision, err)
+				}
+			}
+		default:
+			return "", fmt.Errorf("unknown revision %s", revision)
+		}
+	}
+	return hash, nil
+}
+
+func splitRevisionSuffixes(suffixes string) []string {
+	var result []string
+	for len(suffixes) > 0 {
+		end := 1
+		for end < len(suffixes) && suffixes[end] >= '0' && suffixes[end] <= '9' {
+			end++
+		}
+		result = append(result, suffixes[:end])
+		suffixes = suffixes[end:]
+	}
+	return result
+}
+
+func (r *GitRepository) resolveName(name string) (string, error) {
+	if name == "HEAD" || name == "@" {
+		return r.resolveRef("HEAD")
+	}
+	// the same order as in git rev-parse
+	for _, ref := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"} {
+		// other files of git dir like config are not refs
+		if ref == name && !strings.HasPrefix(name, "refs/") && !isPseudoRef(name) {
+			continue
+		}
+		if hash, err := r.resolveRef(ref); err == nil {
+			return hash, nil
+		}
+	}
+	if len(name) >= 4 && len(name) <= 40 && isHex(name) {
+		return r.findObjectByPrefix(strings.ToLower(name))
+	}
+	return "", errors.New("no such ref")
+}
+
+// isPseudoRef checks name of refs kept in git dir root like FETCH_HEAD and ORIG_HEAD, git allows upper case, '_' and '-' in them
+func isPseudoRef(name string) bool {
+	for _, c := range name {
+		if (c < 'A' || c > 'Z') && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return len(name) > 0
+}
+
+func (r *GitRepository) resolveRef(ref string) (string, error) {
+	for depth := 0; depth < 10; depth++ {
+		content, err := ioutil.ReadFile(filepath.Join(r.gitDir, filepath.FromSlash(ref)))
+		if os.IsNotExist(err) && r.commonDir != r.gitDir {
+			content, err = ioutil.ReadFile(filepath.Join(r.commonDir, filepath.FromSlash(ref)))
+		}
+		if err != nil {
+			return r.resolvePackedRef(ref)
+		}
+		line := strings.TrimSpace(string(content))
+		if !strings.HasPrefix(line, "ref: ") {
+			return line, nil
+		}
+		ref = strings.TrimPrefix(line, "ref: ")
+	}
+	return "", fmt.Errorf("too deep symbolic ref %s", ref)
+}
+
+func (r *GitRepository) resolvePackedRef(ref string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no ref %s", ref)
+}
+
+func (r *GitRepository) findObjectByPrefix(prefix string) (string, error) {
+	found := map[string]bool{}
+	if len(prefix) == 40 {
+		found[prefix] = true
+	} else {
+		looseObjects, _ := ioutil.ReadDir(filepath.Join(r.commonDir, "objects", prefix[:2]))
+		for _, looseObject := range looseObjects {
+			if hash := prefix[:2] + looseObject.Name(); strings.HasPrefix(hash, prefix) {
+				found[hash] = true
+			}
+		}
+		for _, pack := range r.packs {
+			for _, hash := range pack.findByPrefix(prefix) {
+				found[hash] = true
+			}
+		}
+	}
+	if len(found) > 1 {
+		return "", fmt.Errorf("ambiguous hash %s", prefix)
+	}
+	for hash := range found {
+		return hash, nil
+	}
+	return "", fmt.Errorf("no object %s", prefix)
+}
+
+// peelToCommit follows annotated tags to the commit
+func (r *GitRepository) peelToCommit(hash string) (string, error) {
+	for {
+		objectType, content, err := r.readObject(hash)
+		if err != nil {
+			return "", err
+		}
+		switch objectType {
+		case gitCommit:
+			return hash, nil
+		case gitTag:
+			if hash = readGitHeader(content, "object"); len(hash) == 0 {
+				return "", fmt.Errorf("tag without object")
+			}
+		default:
+			return "", fmt.Errorf("%s is not a commit", hash)
+		}
+	}
+}
+
+func (r *GitRepository) commitParent(hash string, n int) (string, error) {
+	_, content, err := r.readObject(hash)
+	if err != nil {
+		return "", err
+	}
+	parents := readGitHeaders(content, "parent")
+	if n > len(parents) {
+		return "", fmt.Errorf("commit %s has no parent %d", hash, n)
+	}
+	return parents[n-1], nil
+}
+
+// CommitTree returns hash of root tree of commit
+func (r *GitRepository) CommitTree(hash string) (string, error) {
+	objectType, content, err := r.readObject(hash)
+	if err != nil {
+		return "", err
+	}
+	if objectType != gitCommit {
+		return "", fmt.Errorf("%s is not a commit", hash)
+	}
+	return readGitHeader(content, "tree"), nil
+}
+
+func readGitHeader(content []byte, name string) string {
+	if values := readGitHeaders(content, name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// readGitHeaders returns values of header lines of commit or tag, headers end with empty line
+func readGitHeaders(content []byte, name string) []string {
+	var values []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if len(line) == 0 {
+			break
+		}
+		if strings.HasPrefix(line, name+" ") {
+			values = append(values, strings.TrimPrefix(line, name+" "))
+		}
+	}
+	return values
+}
+
+// readTree returns entries of tree, they are shared by all callers and must not be modified
+func (r *GitRepository) readTree(hash string) ([]*gitTreeEntry, error) {
+	r.treesLock.Lock()
+	entries, ok := r.trees[hash]
+	r.treesLock.Unlock()
+	if ok {
+		return entries, nil
+	}
+
+	objectType, content, err := r.readObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if objectType != gitTree {
+		return nil, fmt.Errorf("%s is not a tree", hash)
+	}
+	for len(content) > 0 {
+		space := bytes.IndexByte(content, ' ')
+		zero := bytes.IndexByte(content, 0)
+		if space < 0 || zero < space || len(content) < zero+21 {
+			return nil, fmt.Errorf("broken tree %s", hash)
+		}
+		entries = append(entries, &gitTreeEntry{
+			mode: string(content[:space]),
+			name: string(content[space+1 : zero]),
+			hash: hex.EncodeToString(content[zero+1 : zero+21]),
+		})
+		content = content[zero+21:]
+	}
+	r.treesLock.Lock()
+	r.trees[hash] = entries
+	r.treesLock.Unlock()
+	return entries, nil
+}
+
+func (r *GitRepository) readBlob(hash string) ([]byte, error) {
+	objectType, content, err := r.readObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if objectType != gitBlob {
+		return nil, fmt.Errorf("%s is not a blob", hash)
+	}
+	return content, nil
+}
+
+func (r *GitRepository) readObject(hash string) (gitObjectType, []byte, error) {
+	if len(hash) != 40 || !isHex(hash) {
+		return 0, nil, fmt.Errorf("bad object hash %q", hash)
+	}
+	for _, pack := range r.packs {
+		if offset, ok := pack.findOffset(hash); ok {
+			return pack.readObject(r, offset)
+		}
+	}
+	return r.readLooseObject(hash)
+}
+
+func (r *GitRepository) readLooseObject(hash string) (gitObjectType, []byte, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "objects", hash[:2], hash[2:]))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil, fmt.Errorf("no object %s", hash)
+		}
+		return 0, nil, err
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+	content, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return 0, nil, err
+	}
+	zero := bytes.IndexByte(content, 0)
+	if zero < 0 {
+		return 0, nil, fmt.Errorf("broken object %s", hash)
+	}
+	header := strings.Fields(string(content[:zero]))
+	if len(header) != 2 || gitObjectTypes[header[0]] == 0 {
+		return 0, nil, fmt.Errorf("broken object %s", hash)
+	}
+	return gitObjectTypes[header[0]], content[zero+1:], nil
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// gitPack is a pack file with its version 2 index, the file is kept open until repository is closed
+type gitPack struct {
+	path    string
+	file    *os.File
+	hashes  []string
+	offsets []int64
+}
+
+func openGitPack(idxPath string) (*gitPack, error) {
+	idx, err := ioutil.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	broken := &FileError{Path: idxPath, Err: errors.New("unsupported pack index")}
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, broken
+	}
+	count := int(binary.BigEndian.Uint32(idx[8+255*4:]))
+	hashesStart := 8 + 256*4
+	offsetsStart := hashesStart + count*20 + count*4
+	largeOffsetsStart := offsetsStart + count*4
+	if len(idx) < largeOffsetsStart {
+		return nil, broken
+	}
+
+	pack := &gitPack{
+		path:    strings.TrimSuffix(idxPath, ".idx") + ".pack",
+		hashes:  make([]string, count),
+		offsets: make([]int64, count),
+	}
+	for i := 0; i < count; i++ {
+		pack.hashes[i] = hex.EncodeToString(idx[hashesStart+i*20 : hashesStart+i*20+20])
+		offset := binary.BigEndian.Uint32(idx[offsetsStart+i*4:])
+		if offset&0x80000000 == 0 {
+			pack.offsets[i] = int64(offset)
+			continue
+		}
+		largeOffset := largeOffsetsStart + int(offset&0x7fffffff)*8
+		if len(idx) < largeOffset+8 {
+			return nil, broken
+		}
+		pack.offsets[i] = int64(binary.BigEndian.Uint64(idx[largeOffset:]))
+	}
+	if pack.file, err = os.Open(pack.path); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// findOffset looks up object in sorted hashes of index
+func (p *gitPack) findOffset(hash string) (int64, bool) {
+	i := sort.SearchStrings(p.hashes, hash)
+	if i < len(p.hashes) && p.hashes[i] == hash {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+func (p *gitPack) findByPrefix(prefix string) []string {
+	var found []string
+	for i := sort.SearchStrings(p.hashes, prefix); i < len(p.hashes) && strings.HasPrefix(p.hashes[i], prefix); i++ {
+		found = append(found, p.hashes[i])
+	}
+	return found
+}
+
+// readObject reads object at offset of pack, deltas are applied to their base objects.
+// Pack file is read with ReadAt only, so objects can be read concurrently.
+func (p *gitPack) readObject(repo *GitRepository, offset int64) (gitObjectType, []byte, error) {
+	return p.readObjectAt(repo, offset, 0)
+}
+
+func (p *gitPack) readObjectAt(repo *GitRepository, offset int64, depth int) (gitObjectType, []byte, error) {
+	if depth > 100 {
+		return 0, nil, &FileError{Path: p.path, Err: errors.New("too long delta chain")}
+	}
+	r := bufio.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	objectType := gitObjectType((c >> 4) & 7)
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var baseType gitObjectType
+	var base []byte
+	switch objectType {
+	case gitCommit, gitTree, gitBlob, gitTag:
+		content, err := readZlib(r)
+		return objectType, content, err
+	case gitOfsDelta:
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		baseDistance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			baseDistance = (baseDistance+1)<<7 | int64(c&0x7f)
+		}
+		baseType, base, err = p.readObjectAt(repo, offset-baseDistance, depth+1)
+		if err != nil {
+			return 0, nil, err
+		}
+	case gitRefDelta:
+		baseHash := make([]byte, 20)
+		if _, err := io.ReadFull(r, baseHash); err != nil {
+			return 0, nil, err
+		}
+		baseType, base, err = repo.readObject(hex.EncodeToString(baseHash))
+		if err != nil {
+			return 0, nil, err
+		}
+	default:
+		return 0, nil, &FileError{Path: p.path, Err: fmt.Errorf("unknown object type %d at %d", objectType, offset)}
+	}
+
+	delta, err := readZlib(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	content, err := applyGitDelta(base, delta)
+	if err != nil {
+		return 0, nil, &FileError{Path: p.path, Err: err}
+	}
+	return baseType, content, nil
+}
+
+func readZlib(r io.Reader) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+func applyGitDelta(base []byte, delta []byte) ([]byte, error) {
+	brokenDelta := errors.New("broken delta")
+	readSize := func() (int, bool) {
+		size, shift := 0, uint(0)
+		for len(delta) > 0 {
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+	baseSize, ok := readSize()
+	if !ok || baseSize != len(base) {
+		return nil, brokenDelta
+	}
+	resultSize, ok := readSize()
+	if !ok {
+		return nil, brokenDelta
+	}
+
+	result := make([]byte, 0, resultSize)
+	for len(delta) > 0 {
+		cmd := delta[0]
+		delta = delta[1:]
+		if cmd&0x80 != 0 {
+			// copy from base, bits tell which bytes of offset and size are present
+			var copyOffset, copySize int
+			for i := uint(0); i < 7; i++ {
+				if cmd&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, brokenDelta
+				}
+				if i < 4 {
+					copyOffset |= int(delta[0]) << (8 * i)
+				} else {
+					copySize |= int(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if copySize == 0 {
+				copySize = 0x10000
+			}
+			if copyOffset+copySize > len(base) {
+				return nil, brokenDelta
+			}
+			result = append(result, base[copyOffset:copyOffset+copySize]...)
+		} else if cmd != 0 {
+			// insert next cmd bytes
+			if int(cmd) > len(delta) {
+				return nil, brokenDelta
+			}
+			result = append(result, delta[:cmd]...)
+			delta = delta[cmd:]
+		} else {
+			return nil, brokenDelta
+		}
+	}
+	if len(result) != resultSize {
+		return nil, brokenDelta
+	}
+	return result, nil
+}
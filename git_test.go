@@ -0,0 +1,305 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/git/dotgit is .git of a repository with seven commits of CarA/sequences/SeqA.xml and README.
+// Commit n has endpoint Epn in SeqA.xml and "readme n" in README, commit 7 changes SeqA.xml only.
+// Commits 1-6 are packed, their SeqA.xml blobs form a delta chain of depth 5, master and annotated tag v1
+// are in packed-refs. Commit 7 is loose and master points to it by a loose ref.
+const (
+	fixtureCommit1 = "ce21664b7a6d3771f481d5a742bd043754157087"
+	fixtureCommit5 = "fc6ebcbff42713f769d6797e2779ad71916c12c5"
+	fixtureCommit6 = "689d229b281e646b65132d09a16af98d402d8636"
+	fixtureCommit7 = "0da2b29eccc6150b4e57384712603b4088a641c4"
+)
+
+// openFixtureRepository copies fixture to a temporary work tree, git doesn't track directories named .git
+func openFixtureRepository(t *testing.T) (*GitRepository, string) {
+	workTree, err := ioutil.TempDir("", "deps-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(workTree) })
+	if err := copyDir(filepath.Join("testdata", "git", "dotgit"), filepath.Join(workTree, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := OpenGitRepository(workTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo, workTree
+}
+
+func copyDir(from string, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+}
+
+// readFixtureFile reads file of commit by walking trees from root and checks blob content against its hash
+func readFixtureFile(t *testing.T, repo *GitRepository, commitHash string, relPath string) string {
+	treeHash, err := repo.CommitTree(commitHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := (&gitRevisionTree{repo: repo, treeHash: treeHash}).findEntry(filepath.FromSlash(relPath))
+	if err != nil {
+		t.Fatalf("%s at %s: %s", relPath, commitHash, err)
+	}
+	content, err := repo.readBlob(entry.hash)
+	if err != nil {
+		t.Fatalf("%s at %s: %s", relPath, commitHash, err)
+	}
+	hash := sha1.Sum(append([]byte(fmt.Sprintf("blob %d\x00", len(content))), content...))
+	if hex.EncodeToString(hash[:]) != entry.hash {
+		t.Fatalf("%s at %s: content doesn't match blob %s", relPath, commitHash, entry.hash)
+	}
+	return string(content)
+}
+
+func TestFixtureObjectsStorage(t *testing.T) {
+	repo, _ := openFixtureRepository(t)
+	isPacked := func(hash string) bool {
+		for _, pack := range repo.packs {
+			if _, ok := pack.findOffset(hash); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if isPacked(fixtureCommit7) {
+		t.Errorf("commit 7 should be loose")
+	}
+	if !isPacked(fixtureCommit5) {
+		t.Errorf("commit 5 should be packed")
+	}
+
+	// the first byte of packed object has its type, SeqA.xml of commit 5 has to be a delta
+	treeHash, _ := repo.CommitTree(fixtureCommit5)
+	entry, err := (&gitRevisionTree{repo: repo, treeHash: treeHash}).findEntry(filepath.Join("CarA", "sequences", "SeqA.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, ok := repo.packs[0].findOffset(entry.hash)
+	if !ok {
+		t.Fatalf("blob %s should be packed", entry.hash)
+	}
+	header := make([]byte, 1)
+	if _, err := repo.packs[0].file.ReadAt(header, offset); err != nil {
+		t.Fatal(err)
+	}
+	if objectType := gitObjectType((header[0] >> 4) & 7); objectType != gitOfsDelta {
+		t.Errorf("blob %s has type %d, expected offset delta", entry.hash, objectType)
+	}
+}
+
+func TestResolveRevision(t *testing.T) {
+	repo, _ := openFixtureRepository(t)
+	tests := []struct {
+		revision string
+		commit   string
+		version  int
+		readme   int
+	}{
+		{"HEAD", fixtureCommit7, 7, 6},
+		{"@", fixtureCommit7, 7, 6},
+		{"master", fixtureCommit7, 7, 6},
+		{"refs/heads/master", fixtureCommit7, 7, 6},
+		{"HEAD~2", fixtureCommit5, 5, 5},
+		{"HEAD^^", fixtureCommit5, 5, 5},
+		{"HEAD~1^1", fixtureCommit5, 5, 5},
+		{"HEAD^", fixtureCommit6, 6, 6},
+		{"689d229", fixtureCommit6, 6, 6},
+		{"v1", fixtureCommit1, 1, 1},
+		{"tags/v1", fixtureCommit1, 1, 1},
+		{"v1~0", fixtureCommit1, 1, 1},
+		{fixtureCommit7, fixtureCommit7, 7, 6},
+	}
+	for _, test := range tests {
+		t.Run(test.revision, func(t *testing.T) {
+			commit, err := repo.ResolveRevision(test.revision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if commit != test.commit {
+				t.Fatalf("got %s, expected %s", commit, test.commit)
+			}
+			seq := readFixtureFile(t, repo, commit, "CarA/sequences/SeqA.xml")
+			if endpoint := fmt.Sprintf(`<endpoint key="Ep%d"/>`, test.version); !strings.Contains(seq, endpoint) {
+				t.Errorf("SeqA.xml has no %s", endpoint)
+			}
+			if readme := readFixtureFile(t, repo, commit, "README"); readme != fmt.Sprintf("readme %d\n", test.readme) {
+				t.Errorf("README is %q", readme)
+			}
+		})
+	}
+}
+
+func TestResolveUnknownRevision(t *testing.T) {
+	repo, _ := openFixtureRepository(t)
+	for _, revision := range []string{"HEAD~7", "HEAD^2", "v2", "0000000", "master~x"} {
+		if commit, err := repo.ResolveRevision(revision); err == nil {
+			t.Errorf("%s resolved to %s", revision, commit)
+		}
+	}
+}
+
+func TestResolveRefsNamedAsGitDirFiles(t *testing.T) {
+	repo, workTree := openFixtureRepository(t)
+	gitDir := filepath.Join(workTree, ".git")
+	writeRef := func(ref string, hash string) {
+		path := filepath.Join(gitDir, filepath.FromSlash(ref))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(hash+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeRef("config", "[core]\n\tbare = false")
+	writeRef("description", fixtureCommit1)
+	writeRef("refs/heads/config", fixtureCommit5)
+	writeRef("refs/tags/description", fixtureCommit6)
+	writeRef("ORIG_HEAD", fixtureCommit6)
+	tests := []struct {
+		revision string
+		commit   string
+	}{
+		{"config", fixtureCommit5},
+		{"description", fixtureCommit6},
+		{"ORIG_HEAD", fixtureCommit6},
+		{"config~0", fixtureCommit5},
+	}
+	for _, test := range tests {
+		if commit, err := repo.ResolveRevision(test.revision); err != nil || commit != test.commit {
+			t.Errorf("%s resolved to %s, %v, expected %s", test.revision, commit, err, test.commit)
+		}
+	}
+}
+
+func TestGitFileSystem(t *testing.T) {
+	_, workTree := openFixtureRepository(t)
+	fs := newGitFileSystem("HEAD~2")
+	defer fs.Close()
+
+	var files []string
+	err := fs.Walk(workTree, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Name() == ".git" {
+			t.Errorf(".git is not in revision tree")
+		}
+		if !info.IsDir() {
+			relPath, _ := filepath.Rel(workTree, path)
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(files, ",") != "CarA/sequences/SeqA.xml,README" {
+		t.Errorf("walked %v", files)
+	}
+
+	readme, err := fs.ReadFile(filepath.Join(workTree, "README"))
+	if err != nil || string(readme) != "readme 5\n" {
+		t.Errorf("README is %q, %v", readme, err)
+	}
+	if _, err := fs.ReadFile(filepath.Join(workTree, "CarA")); err == nil {
+		t.Errorf("directory read as file")
+	}
+	if _, err := fs.ReadFile(filepath.Join(workTree, "missing.xml")); err == nil {
+		t.Errorf("missing file read")
+	}
+}
+
+func TestApplyGitDelta(t *testing.T) {
+	base := []byte("hello world")
+	tests := []struct {
+		name   string
+		delta  []byte
+		result string
+	}{
+		// sizes are followed by commands, copy command has offset and size bytes selected by its bits
+		{"copy", []byte{11, 5, 0x90, 5}, "hello"},
+		{"copy with offset", []byte{11, 5, 0x91, 6, 5}, "world"},
+		{"insert", []byte{11, 3, 3, 'b', 'y', 'e'}, "bye"},
+		{"copy and insert", []byte{11, 6, 0x90, 5, 1, '!'}, "hello!"},
+		{"wrong base size", []byte{10, 5, 0x90, 5}, ""},
+		{"copy out of base", []byte{11, 5, 0x91, 8, 5}, ""},
+		{"wrong result size", []byte{11, 4, 0x90, 5}, ""},
+		{"short insert", []byte{11, 3, 3, 'b'}, ""},
+		{"zero command", []byte{11, 0, 0}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := applyGitDelta(base, test.delta)
+			if len(test.result) == 0 {
+				if err == nil {
+					t.Fatalf("got %q, expected error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != test.result {
+				t.Fatalf("got %q, expected %q", result, test.result)
+			}
+		})
+	}
+}
+
+func TestReadTreeIsCached(t *testing.T) {
+	repo, _ := openFixtureRepository(t)
+	treeHash, err := repo.CommitTree(fixtureCommit5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.readTree(treeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.readTree(treeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) == 0 || &first[0] != &second[0] {
+		t.Errorf("tree %s decoded twice", treeHash)
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/goccy/go-graphviz"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+type htmlReport struct {
+	Title string
+	Svg   template.HTML
+	Graph template.JS
+}
+
+// printHtmlReport writes self-contained page with cars graph laid out by graphviz and artifacts dependencies
+// of allowed cars, the page needs no network
+func printHtmlReport(dependenciesMap *map[string]map[string]*CarDependency, artifacts map[string]*Artifact, outPath string, carNames []string, ignoreCarRegex string, findType string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+
+	g := graphviz.New()
+	graph, err := g.Graph()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := graph.Close(); err != nil {
+			log.Fatal(err)
+		}
+		g.Close()
+	}()
+	// nodes have no links, clicks select them
+	addCarsToGraph(graph, dependenciesMap, isCarAllowed, nil)
+
+	var svg bytes.Buffer
+	if err := g.Render(graph, graphviz.SVG, &svg); err != nil {
+		panic(err)
+	}
+	// xml declaration and doctype are not allowed inside html
+	svgBytes := svg.Bytes()
+	if i := bytes.Index(svgBytes, []byte("<svg")); i >= 0 {
+		svgBytes = svgBytes[i:]
+	}
+
+	// json encoder escapes <, > and &, so graph is safe inside script
+	graphJson, err := json.Marshal(NewJsonGraph(dependenciesMap, artifacts, carNames, ignoreCarRegex, findType))
+	if err != nil {
+		panic(err)
+	}
+
+	f, err := os.Create(filepath.Join(outPath, findType+"-graph.html"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	report := htmlReport{
+		Title: "car-apps dependencies (" + findType + ")",
+		Svg:   template.HTML(svgBytes),
+		Graph: template.JS(graphJson),
+	}
+	if err := htmlReportTemplate.Execute(f, report); err != nil {
+		panic(err)
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+  html, body { margin: 0; height: 100%; font: 13px sans-serif; color: #24292f; }
+  body { display: flex; flex-direction: column; }
+  header { display: flex; gap: 8px; align-items: center; padding: 6px 10px; border-bottom: 1px solid #d0d7de; background: #f6f8fa; }
+  header h1 { font-size: 14px; margin: 0 12px 0 0; }
+  header input { width: 260px; padding: 3px 6px; }
+  main { flex: 1; display: flex; min-height: 0; }
+  #graph { flex: 1; overflow: hidden; cursor: grab; background: white; }
+  #graph.panning { cursor: grabbing; }
+  #graph svg { width: 100%; height: 100%; }
+  #panel { width: 380px; overflow: auto; padding: 8px 12px; border-left: 1px solid #d0d7de; }
+  #panel h2 { font-size: 14px; margin: 4px 0 8px; word-break: break-all; }
+  #panel h3 { font-size: 13px; margin: 12px 0 4px; }
+  #panel ul { margin: 2px 0 6px; padding-left: 18px; }
+  #panel li { margin: 2px 0; word-break: break-all; }
+  #panel .type, #panel .file { color: #57606a; font-size: 11px; }
+  #panel .file { display: block; }
+  a.car { color: #0969da; cursor: pointer; text-decoration: none; }
+  a.car:hover { text-decoration: underline; }
+  g.node, g.edge { cursor: pointer; }
+  g.faded { opacity: 0.15; }
+  g.node.selected > ellipse, g.node.selected > polygon { stroke: #0969da; stroke-width: 3; }
+  g.node.match > ellipse, g.node.match > polygon { fill: #fff8c5; stroke: #9a6700; stroke-width: 2; }
+  g.edge.outgoing > path, g.edge.outgoing > polygon { stroke: #0969da; stroke-width: 2; }
+  g.edge.incoming > path, g.edge.incoming > polygon { stroke: #bc4c00; stroke-width: 2; }
+  g.edge.selected > path, g.edge.selected > polygon { stroke: #0969da; stroke-width: 3; }
+</style>
+</head>
+<body>
+<header>
+  <h1>{{.Title}}</h1>
+  <input id="search" type="search" placeholder="search car or artifact, Enter selects">
+  <button id="fit">fit</button>
+  <span id="summary"></span>
+</header>
+<main>
+  <div id="graph">{{.Svg}}</div>
+  <div id="panel"></div>
+</main>
+<script>
+(function () {
+  var graph = {{.Graph}};
+
+  // cars with their dependencies, incoming ones are collected from outgoing ones of other cars
+  var cars = {};
+  var dependencies = {};
+  function getCar(name) {
+    if (!cars[name]) {
+      cars[name] = { name: name, outgoing: [], incoming: [], artifacts: {} };
+    }
+    return cars[name];
+  }
+  graph.cars.forEach(function (car) {
+    getCar(car.name);
+    car.dependencies.forEach(function (dependency) {
+      var dep = { from: car.name, to: dependency.car, artifactDependencies: dependency.artifactDependencies };
+      getCar(car.name).outgoing.push(dep);
+      getCar(dependency.car).incoming.push(dep);
+      dependency.artifactDependencies.forEach(function (artifactDependency) {
+        getCar(car.name).artifacts[artifactDependency.from.name] = true;
+        getCar(dependency.car).artifacts[artifactDependency.to.name] = true;
+      });
+      dependencies[car.name + "->" + dependency.car] = dep;
+    });
+  });
+  var carNames = Object.keys(cars).sort();
+
+  var svg = document.querySelector("#graph svg");
+  var panel = document.getElementById("panel");
+  var nodeElements = {};
+  var edgeElements = [];
+
+  document.getElementById("summary").textContent = carNames.length + " cars, " + Object.keys(dependencies).length + " dependencies";
+
+  function element(tag, text, className) {
+    var e = document.createElement(tag);
+    if (text) {
+      e.textContent = text;
+    }
+    if (className) {
+      e.className = className;
+    }
+    return e;
+  }
+
+  function carLink(name) {
+    var a = element("a", name, "car");
+    a.addEventListener("click", function () {
+      selectCar(name, true);
+    });
+    return a;
+  }
+
+  function artifactItem(artifact) {
+    var span = element("span", artifact.name);
+    if (artifact.type) {
+      span.appendChild(document.createTextNode(" "));
+      span.appendChild(element("span", artifact.type, "type"));
+    }
+    if (artifact.file) {
+      span.appendChild(element("span", artifact.file, "file"));
+    }
+    return span;
+  }
+
+  // artifactDependenciesList expands car dependency into artifact dependencies
+  function artifactDependenciesList(dep) {
+    var ul = element("ul");
+    dep.artifactDependencies.forEach(function (artifactDependency) {
+      var li = element("li");
+      li.appendChild(artifactItem(artifactDependency.from));
+      li.appendChild(document.createTextNode(" → "));
+      li.appendChild(artifactItem(artifactDependency.to));
+      ul.appendChild(li);
+    });
+    return ul;
+  }
+
+  function dependenciesSection(title, deps, otherCar) {
+    panel.appendChild(element("h3", title + " (" + deps.length + ")"));
+    deps.slice().sort(function (a, b) {
+      return otherCar(a) < otherCar(b) ? -1 : 1;
+    }).forEach(function (dep) {
+      var details = element("details");
+      var summary = element("summary");
+      summary.appendChild(carLink(otherCar(dep)));
+      summary.appendChild(document.createTextNode(" (" + dep.artifactDependencies.length + " artifact links)"));
+      details.appendChild(summary);
+      details.appendChild(artifactDependenciesList(dep));
+      panel.appendChild(details);
+    });
+  }
+
+  function showOverview() {
+    panel.innerHTML = "";
+    panel.appendChild(element("h2", "Cars"));
+    var ul = element("ul");
+    carNames.forEach(function (name) {
+      var li = element("li");
+      li.appendChild(carLink(name));
+      li.appendChild(document.createTextNode(" ← " + cars[name].incoming.length + ", → " + cars[name].outgoing.length));
+      ul.appendChild(li);
+    });
+    panel.appendChild(ul);
+  }
+
+  function showCar(name) {
+    var car = cars[name];
+    panel.innerHTML = "";
+    panel.appendChild(element("h2", name));
+    dependenciesSection("Depends on", car.outgoing, function (dep) { return dep.to; });
+    dependenciesSection("Used by", car.incoming, function (dep) { return dep.from; });
+  }
+
+  function showDependency(dep) {
+    panel.innerHTML = "";
+    var h2 = element("h2");
+    h2.appendChild(carLink(dep.from));
+    h2.appendChild(document.createTextNode(" → "));
+    h2.appendChild(carLink(dep.to));
+    panel.appendChild(h2);
+    panel.appendChild(artifactDependenciesList(dep));
+  }
+
+  function clearHighlight() {
+    Object.keys(nodeElements).forEach(function (name) {
+      nodeElements[name].classList.remove("selected", "related", "match", "faded");
+    });
+    edgeElements.forEach(function (edge) {
+      edge.element.classList.remove("selected", "incoming", "outgoing", "faded");
+    });
+  }
+
+  function selectCar(name, center) {
+    if (!cars[name]) {
+      return;
+    }
+    clearHighlight();
+    var related = {};
+    related[name] = true;
+    edgeElements.forEach(function (edge) {
+      if (edge.dep.from === name) {
+        edge.element.classList.add("outgoing");
+        related[edge.dep.to] = true;
+      } else if (edge.dep.to === name) {
+        edge.element.classList.add("incoming");
+        related[edge.dep.from] = true;
+      } else {
+        edge.element.classList.add("faded");
+      }
+    });
+    Object.keys(nodeElements).forEach(function (carName) {
+      nodeElements[carName].classList.add(carName === name ? "selected" : related[carName] ? "related" : "faded");
+    });
+    showCar(name);
+    if (center && nodeElements[name]) {
+      centerOn(nodeElements[name]);
+    }
+  }
+
+  function selectDependency(dep) {
+    clearHighlight();
+    edgeElements.forEach(function (edge) {
+      edge.element.classList.add(edge.dep === dep ? "selected" : "faded");
+    });
+    Object.keys(nodeElements).forEach(function (carName) {
+      if (carName !== dep.from && carName !== dep.to) {
+        nodeElements[carName].classList.add("faded");
+      }
+    });
+    showDependency(dep);
+  }
+
+  function clearSelection() {
+    clearHighlight();
+    showOverview();
+  }
+
+  // search matches car names and names of artifacts taking part in dependencies of car
+  function findCars(query) {
+    query = query.toLowerCase();
+    return carNames.filter(function (name) {
+      return name.toLowerCase().indexOf(query) >= 0 || Object.keys(cars[name].artifacts).some(function (artifact) {
+        return artifact.toLowerCase().indexOf(query) >= 0;
+      });
+    });
+  }
+
+  var search = document.getElementById("search");
+  search.addEventListener("input", function () {
+    if (!search.value) {
+      clearSelection();
+      return;
+    }
+    var found = findCars(search.value);
+    clearHighlight();
+    edgeElements.forEach(function (edge) {
+      edge.element.classList.add("faded");
+    });
+    Object.keys(nodeElements).forEach(function (name) {
+      nodeElements[name].classList.add(found.indexOf(name) >= 0 ? "match" : "faded");
+    });
+    panel.innerHTML = "";
+    panel.appendChild(element("h2", found.length + " cars found"));
+    var ul = element("ul");
+    found.forEach(function (name) {
+      var li = element("li");
+      li.appendChild(carLink(name));
+      ul.appendChild(li);
+    });
+    panel.appendChild(ul);
+  });
+  search.addEventListener("keydown", function (e) {
+    if (e.key === "Enter") {
+      var found = findCars(search.value);
+      if (found.length > 0) {
+        selectCar(found[0], true);
+      }
+    } else if (e.key === "Escape") {
+      search.value = "";
+      clearSelection();
+    }
+  });
+
+  showOverview();
+  if (!svg) {
+    return;
+  }
+
+  svg.querySelectorAll("g.node").forEach(function (node) {
+    var name = node.querySelector("title").textContent;
+    nodeElements[name] = node;
+    node.addEventListener("click", function (e) {
+      e.stopPropagation();
+      if (!moved) {
+        selectCar(name, false);
+      }
+    });
+  });
+  svg.querySelectorAll("g.edge").forEach(function (edge) {
+    var dep = dependencies[edge.querySelector("title").textContent];
+    if (!dep) {
+      return;
+    }
+    edgeElements.push({ element: edge, dep: dep });
+    edge.addEventListener("click", function (e) {
+      e.stopPropagation();
+      if (!moved) {
+        selectDependency(dep);
+      }
+    });
+  });
+
+  // zoom and pan change view box, points under cursor stay in place
+  svg.removeAttribute("width");
+  svg.removeAttribute("height");
+  var initialViewBox = svg.viewBox.baseVal;
+  var fitView = { x: initialViewBox.x, y: initialViewBox.y, width: initialViewBox.width, height: initialViewBox.height };
+  var view = Object.assign({}, fitView);
+  function applyView() {
+    svg.setAttribute("viewBox", [view.x, view.y, view.width, view.height].join(" "));
+  }
+  function toSvgPoint(clientX, clientY) {
+    var point = svg.createSVGPoint();
+    point.x = clientX;
+    point.y = clientY;
+    return point.matrixTransform(svg.getScreenCTM().inverse());
+  }
+  function centerOn(node) {
+    var box = node.getBBox();
+    var matrix = svg.getScreenCTM().inverse().multiply(node.getScreenCTM());
+    var point = svg.createSVGPoint();
+    point.x = box.x + box.width / 2;
+    point.y = box.y + box.height / 2;
+    point = point.matrixTransform(matrix);
+    view.x = point.x - view.width / 2;
+    view.y = point.y - view.height / 2;
+    applyView();
+  }
+
+  var container = document.getElementById("graph");
+  container.addEventListener("wheel", function (e) {
+    e.preventDefault();
+    var scale = e.deltaY < 0 ? 0.8 : 1.25;
+    var point = toSvgPoint(e.clientX, e.clientY);
+    view.x = point.x - (point.x - view.x) * scale;
+    view.y = point.y - (point.y - view.y) * scale;
+    view.width *= scale;
+    view.height *= scale;
+    applyView();
+  }, { passive: false });
+
+  // click ends selection only when mouse wasn't dragged
+  var panStart = null;
+  var panClientStart = null;
+  var moved = false;
+  container.addEventListener("mousedown", function (e) {
+    panStart = toSvgPoint(e.clientX, e.clientY);
+    panClientStart = { x: e.clientX, y: e.clientY };
+    moved = false;
+    container.classList.add("panning");
+  });
+  window.addEventListener("mousemove", function (e) {
+    if (!panStart) {
+      return;
+    }
+    if (Math.abs(e.clientX - panClientStart.x) + Math.abs(e.clientY - panClientStart.y) > 3) {
+      moved = true;
+    }
+    var point = toSvgPoint(e.clientX, e.clientY);
+    view.x -= point.x - panStart.x;
+    view.y -= point.y - panStart.y;
+    applyView();
+  });
+  window.addEventListener("mouseup", function () {
+    panStart = null;
+    container.classList.remove("panning");
+  });
+  container.addEventListener("click", function () {
+    if (!moved) {
+      clearSelection();
+    }
+  });
+  document.getElementById("fit").addEventListener("click", function () {
+    view = Object.assign({}, fitView);
+    applyView();
+  });
+})();
+</script>
+</body>
+</html>
+`))
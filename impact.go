@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImpactedArtifact is an artifact transitively depending on one of changed artifacts
+type ImpactedArtifact struct {
+	Name  string
+	Car   string
+	Depth int
+	// Path goes from the impacted artifact to the changed one
+	Path []string
+}
+
+type dependentArtifact struct {
+	name string
+	car  string
+}
+
+// findImpact returns artifacts transitively depending on given artifacts or artifacts of given cars,
+// found by breadth-first search, so every artifact is reached by one of its shortest paths
+func findImpact(dependenciesMap *map[string]map[string]*CarDependency, artifactsToCarMap map[string]string, names []string, isCarAllowed func(carName string) bool) []*ImpactedArtifact {
+	dependents := map[string][]dependentArtifact{}
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		if !isCarAllowed(carFrom) {
+			continue
+		}
+		for _, dependency := range (*dependenciesMap)[carFrom] {
+			for fromArtifact, toArtifacts := range dependency.ArtifactDependencies {
+				for toArtifact := range toArtifacts {
+					dependents[toArtifact] = append(dependents[toArtifact], dependentArtifact{fromArtifact, carFrom})
+				}
+			}
+		}
+	}
+	for _, artifacts := range dependents {
+		sort.Slice(artifacts, func(i, j int) bool {
+			return artifacts[i].name < artifacts[j].name
+		})
+	}
+
+	visited := map[string]bool{}
+	var queue []*ImpactedArtifact
+	isCar := map[string]bool{}
+	for _, name := range names {
+		isCar[name] = (*dependenciesMap)[name] != nil
+	}
+	for _, artifactName := range getSortedMapKeysFromStringMap(artifactsToCarMap) {
+		carName := artifactsToCarMap[artifactName]
+		for _, name := range names {
+			if name == artifactName || (isCar[name] && name == carName) {
+				visited[artifactName] = true
+				queue = append(queue, &ImpactedArtifact{Name: artifactName, Car: carName, Path: []string{artifactName}})
+			}
+		}
+	}
+
+	var impacted []*ImpactedArtifact
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current.Name] {
+			if visited[dependent.name] {
+				continue
+			}
+			visited[dependent.name] = true
+			next := &ImpactedArtifact{
+				Name:  dependent.name,
+				Car:   dependent.car,
+				Depth: current.Depth + 1,
+				Path:  append([]string{dependent.name}, current.Path...),
+			}
+			impacted = append(impacted, next)
+			queue = append(queue, next)
+		}
+	}
+	return impacted
+}
+
+func printImpact(dependenciesMap *map[string]map[string]*CarDependency, artifactsToCarMap map[string]string, names []string, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string) {
+	f, err := os.Create(filepath.Join(outPath, fileNamePrefix+"impact.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	writeImpact(w, dependenciesMap, artifactsToCarMap, names, carNames, ignoreCarRegex)
+
+	w.Flush()
+}
+
+func writeImpact(w *bufio.Writer, dependenciesMap *map[string]map[string]*CarDependency, artifactsToCarMap map[string]string, names []string, carNames []string, ignoreCarRegex string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+
+	for _, name := range names {
+		impacted := findImpact(dependenciesMap, artifactsToCarMap, []string{name}, isCarAllowed)
+		w.WriteString(name + "\n")
+		impactedCars := map[string]bool{}
+		for _, artifact := range impacted {
+			impactedCars[artifact.Car] = true
+			w.WriteString("  " + strconv.Itoa(artifact.Depth) + " " + artifact.Car + ": " + strings.Join(artifact.Path, " -> ") + "\n")
+		}
+		w.WriteString("  impacted cars: " + strings.Join(getSortedMapKeysFromArtifactsPartMap(impactedCars), ", ") + "\n\n")
+	}
+}
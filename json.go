@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// JsonSchemaVersion is increased on every incompatible change of the json graph format
+const JsonSchemaVersion = 1
+
+type JsonGraph struct {
+	SchemaVersion  int        `json:"schemaVersion"`
+	ExtractionMode string     `json:"extractionMode"`
+	Cars           []*JsonCar `json:"cars"`
+}
+
+type JsonCar struct {
+	Name         string               `json:"name"`
+	Dependencies []*JsonCarDependency `json:"dependencies"`
+}
+
+type JsonCarDependency struct {
+	Car                  string                    `json:"car"`
+	ArtifactDependencies []*JsonArtifactDependency `json:"artifactDependencies"`
+}
+
+type JsonArtifactDependency struct {
+	From           *JsonArtifact `json:"from"`
+	To             *JsonArtifact `json:"to"`
+	ExtractionMode string        `json:"extractionMode"`
+}
+
+type JsonArtifact struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+	File string `json:"file,omitempty"`
+}
+
+func printJsonGraph(dependenciesMap *map[string]map[string]*CarDependency, artifacts map[string]*Artifact, outPath string, carNames []string, ignoreCarRegex string, findType string) {
+	jsonGraph := NewJsonGraph(dependenciesMap, artifacts, carNames, ignoreCarRegex, findType)
+
+	f, err := os.Create(filepath.Join(outPath, findType+"-graph.json"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(jsonGraph); err != nil {
+		panic(err)
+	}
+}
+
+func NewJsonGraph(dependenciesMap *map[string]map[string]*CarDependency, artifacts map[string]*Artifact, carNames []string, ignoreCarRegex string, findType string) *JsonGraph {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	jsonArtifact := func(name string) *JsonArtifact {
+		result := &JsonArtifact{Name: name}
+		if artifact := artifacts[name]; artifact != nil {
+			result.Type = artifact.Type
+			result.File = artifact.Path
+		}
+		return result
+	}
+
+	jsonGraph := &JsonGraph{
+		SchemaVersion:  JsonSchemaVersion,
+		ExtractionMode: findType,
+		Cars:           []*JsonCar{},
+	}
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		if !isCarAllowed(carFrom) {
+			continue
+		}
+		jsonCar := &JsonCar{Name: carFrom, Dependencies: []*JsonCarDependency{}}
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			// dependencies inside one car are written to artifact edges only
+			if carFrom == carTo || !dependency.HaveDependency || !isCarAllowed(carTo) {
+				continue
+			}
+			jsonDependency := &JsonCarDependency{Car: carTo, ArtifactDependencies: []*JsonArtifactDependency{}}
+			for _, fromArtifact := range getSortedMapKeysFromArtifactFullMap(dependency.ArtifactDependencies) {
+				for _, toArtifact := range getSortedMapKeysFromArtifactsPartMap(dependency.ArtifactDependencies[fromArtifact]) {
+					jsonDependency.ArtifactDependencies = append(jsonDependency.ArtifactDependencies, &JsonArtifactDependency{
+						From:           jsonArtifact(fromArtifact),
+						To:             jsonArtifact(toArtifact),
+						ExtractionMode: findType,
+					})
+				}
+			}
+			jsonCar.Dependencies = append(jsonCar.Dependencies, jsonDependency)
+		}
+		jsonGraph.Cars = append(jsonGraph.Cars, jsonCar)
+	}
+	return jsonGraph
+}
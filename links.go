@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// graphLinks makes urls of graph nodes: car nodes link to car directory or archive, artifact nodes to file defining them.
+// Urls are file urls unless url template is given, its {path} is replaced by slash separated path relative to root path.
+type graphLinks struct {
+	carPaths    map[string]string
+	artifacts   map[string]*Artifact
+	rootPaths   []string
+	urlTemplate string
+}
+
+func newGraphLinks(carPaths map[string]string, artifacts map[string]*Artifact, rootPaths []string, urlTemplate string) *graphLinks {
+	return &graphLinks{
+		carPaths:    carPaths,
+		artifacts:   artifacts,
+		rootPaths:   rootPaths,
+		urlTemplate: urlTemplate,
+	}
+}
+
+func (l *graphLinks) carPath(carName string) string {
+	if l == nil {
+		return ""
+	}
+	return l.carPaths[carName]
+}
+
+// artifactPath returns file defining artifact, files inside .car archives are linked by archive
+func (l *graphLinks) artifactPath(artifactName string) string {
+	if l == nil || l.artifacts[artifactName] == nil {
+		return ""
+	}
+	path := l.artifacts[artifactName].Path
+	if i := strings.Index(path, "!/"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// url returns link to path or empty string if path is unknown
+func (l *graphLinks) url(path string) string {
+	if l == nil || len(path) == 0 {
+		return ""
+	}
+	if len(l.urlTemplate) == 0 {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return ""
+		}
+		slashPath := filepath.ToSlash(absPath)
+		if !strings.HasPrefix(slashPath, "/") {
+			slashPath = "/" + slashPath
+		}
+		fileUrl := url.URL{Scheme: "file", Path: slashPath}
+		return fileUrl.String()
+	}
+
+	relPath := path
+	for _, rootPath := range l.rootPaths {
+		if rel, err := filepath.Rel(rootPath, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			relPath = rel
+			break
+		}
+	}
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Replace(l.urlTemplate, "{path}", strings.Join(segments, "/"), -1)
+}
@@ -2,33 +2,173 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 )
 
 func main() {
 	progPath := filepath.Dir(os.Args[0])
-	rootPathPtr := flag.String("path", progPath, "path to project root")
-	outPathPtr := flag.String("outPath", progPath, "path where result will be saved")
-	carNamesPtr := flag.String("carsToAnalyse", "", "names of car-apps to analyse")
-	ignoreCarRegexPtr := flag.String("ignoreCarRegex", "", "regex for ignoring analyse of cars")
-	findByRegexPtr := flag.Bool("findByRegex", false, "if 'true' then artifacts will be found using regex, otherwise by xml parsing")
-	renderBothFindTypesPtr := flag.Bool("renderBothFindTypes", false, "if 'true' then both find types will be rendered")
-	flag.Parse()
-
-	cars := strings.Split(*carNamesPtr, ",")
-	carNames := []string{}
-	for _, car := range cars {
-		carName := strings.Trim(car, " ")
-		if len(carName) > 0 {
-			carNames = append(carNames, string(carName))
+	args := os.Args[1:]
+
+	// without command name the tool renders graph, as before commands were introduced
+	command := findCommand(defaultCommand)
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == "help" {
+			printHelp(progPath, args[1:])
+			return
+		}
+		command = findCommand(args[0])
+		if command == nil {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+			printUsage()
+			os.Exit(2)
 		}
+		args = args[1:]
 	}
+
+	flags := newCommandFlags(command, progPath)
+	config := flags.Config(args)
 	start := time.Now()
-	FindDependencies(*rootPathPtr, *outPathPtr, carNames, *ignoreCarRegexPtr, *findByRegexPtr, *renderBothFindTypesPtr)
-	elapsed := time.Since(start)
-	log.Printf("Took %s", elapsed)
+	exitCode := command.run(config, flags.Args())
+	log.Printf("Took %s", time.Since(start))
+	os.Exit(exitCode)
+}
+
+func printUsage() {
+	progName := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "usage: %s [command] [flags] [args]\n\ncommands:\n", progName)
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", command.name, command.description)
+	}
+	fmt.Fprintf(os.Stderr, "\n%s is run when command is absent. Use '%s help <command>' for command flags.\n", defaultCommand, progName)
+}
+
+func printHelp(progPath string, args []string) {
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+	command := findCommand(args[0])
+	if command == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage()
+		os.Exit(2)
+	}
+	newCommandFlags(command, progPath).Usage()
+}
+
+// configFlags binds command line flags to config settings, flags given explicitly override config file
+type configFlags struct {
+	*flag.FlagSet
+	progPath   string
+	configPath *string
+	rootPaths  *string
+	overrides  map[string]func(config *Config)
+}
+
+// newCommandFlags creates flags shared by all commands and flags of given command
+func newCommandFlags(command *Command, progPath string) *configFlags {
+	f := &configFlags{
+		FlagSet:   flag.NewFlagSet(command.name, flag.ExitOnError),
+		progPath:  progPath,
+		overrides: map[string]func(config *Config){},
+	}
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "usage: %s %s [flags] %s\n\n%s\n\nflags:\n", filepath.Base(os.Args[0]), command.name, command.argsUsage, command.description)
+		f.PrintDefaults()
+	}
+
+	f.configPath = f.String("config", "", "path to json config, if absent "+ConfigFileName+" is looked up in path and working directory")
+	f.rootPaths = f.listVar("path", []string{progPath}, "paths to project roots separated by comma", func(c *Config, v []string) { c.Paths = v })
+	f.listVar("carsToAnalyse", nil, "names of car-apps to analyse", func(c *Config, v []string) { c.CarsToAnalyse = v })
+	f.listVar("ignoreCars", nil, "names of car-apps to ignore", func(c *Config, v []string) { c.IgnoreCars = v })
+	f.stringVar("ignoreCarRegex", "", "regex for ignoring analyse of cars", func(c *Config, v string) { c.IgnoreCarRegex = v })
+	f.listVar("dirsToSkip", defaultDirsToSkip, "name patterns of directories to skip", func(c *Config, v []string) { c.DirsToSkip = v })
+	f.listVar("filesToSkip", defaultFilesToSkip, "name patterns of files to skip", func(c *Config, v []string) { c.FilesToSkip = v })
+	f.boolVar("findByRegex", false, "if 'true' then artifacts will be found using regex, otherwise by xml parsing", func(c *Config, v bool) { c.FindByRegex = v })
+	f.intVar("parallelism", runtime.GOMAXPROCS(0), "number of files analysed in parallel", func(c *Config, v int) { c.Parallelism = v })
+	f.boolVar("strict", false, "if 'true' then exit code will be 1 when some files can't be analysed", func(c *Config, v bool) { c.Strict = v })
+	f.stringVar("revision", "", "git revision to analyse instead of work tree, e.g. HEAD~1 or a tag, files are read from .git of repository containing path", func(c *Config, v string) { c.Revision = v })
+	f.stringVar("input", SourcesInput, "what to analyse under path: 'sources' of car projects, built .car archives ('cars') or a server 'deployment'", func(c *Config, v string) { c.Input = v })
+	if command.addFlags != nil {
+		command.addFlags(f)
+	}
+	return f
+}
+
+func (f *configFlags) stringVar(name string, value string, usage string, set func(config *Config, value string)) *string {
+	ptr := f.String(name, value, usage)
+	f.overrides[name] = func(config *Config) { set(config, *ptr) }
+	return ptr
+}
+
+// listVar binds flag with comma separated values
+func (f *configFlags) listVar(name string, value []string, usage string, set func(config *Config, value []string)) *string {
+	ptr := f.String(name, strings.Join(value, ","), usage)
+	f.overrides[name] = func(config *Config) { set(config, splitNames(*ptr)) }
+	return ptr
+}
+
+func (f *configFlags) boolVar(name string, value bool, usage string, set func(config *Config, value bool)) *bool {
+	ptr := f.Bool(name, value, usage)
+	f.overrides[name] = func(config *Config) { set(config, *ptr) }
+	return ptr
+}
+
+func (f *configFlags) intVar(name string, value int, usage string, set func(config *Config, value int)) *int {
+	ptr := f.Int(name, value, usage)
+	f.overrides[name] = func(config *Config) { set(config, *ptr) }
+	return ptr
+}
+
+// Config parses args and returns default config overridden by config file and then by flags given explicitly
+func (f *configFlags) Config(args []string) *Config {
+	f.Parse(args)
+
+	setFlags := map[string]bool{}
+	f.Visit(func(flag *flag.Flag) {
+		setFlags[flag.Name] = true
+	})
+
+	config := NewDefaultConfig(f.progPath)
+	configPath := *f.configPath
+	if len(configPath) == 0 {
+		configDirs := []string{"."}
+		if rootPaths := splitNames(*f.rootPaths); setFlags["path"] && len(rootPaths) > 0 {
+			configDirs = append([]string{rootPaths[0]}, configDirs...)
+		}
+		configPath = findConfigFile(configDirs...)
+	}
+	if len(configPath) > 0 {
+		if err := LoadConfig(configPath, config); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("Loaded config %s", configPath)
+	}
+
+	for name := range setFlags {
+		if override := f.overrides[name]; override != nil {
+			override(config)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		log.Fatalln(err)
+	}
+	return config
+}
+
+func splitNames(namesStr string) []string {
+	names := []string{}
+	for _, name := range strings.Split(namesStr, ",") {
+		name = strings.Trim(name, " ")
+		if len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
 }
@@ -1,6 +1,11 @@
 package main
 
-type CarArtifacts map[string][]string
+type CarArtifacts map[string][]*Artifact
+
+type CarArtifact struct {
+	Car      string
+	Artifact *Artifact
+}
 
 type CarDependency struct {
 	HaveDependency       bool
@@ -19,3 +24,10 @@ func NewCarDependency() *CarDependency {
 		ArtifactDependencies: map[string]map[string]bool{},
 	}
 }
+
+// AnalysisResult holds findings which may fail the run
+type AnalysisResult struct {
+	Cycles            [][]string
+	MissingReferences int
+	Errors            int
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// network is a graph with attributes of nodes and edges for graph tools like yEd and Gephi.
+// Cars and artifacts have the same attributes, car nodes have type "car" and path of car as file.
+type network struct {
+	nodes []*networkNode
+	edges []*networkEdge
+}
+
+type networkNode struct {
+	id       string
+	label    string
+	car      string
+	nodeType string
+	file     string
+	fanIn    int
+	fanOut   int
+}
+
+type networkEdge struct {
+	id             string
+	from           *networkNode
+	to             *networkNode
+	artifactLinks  int
+	extractionMode string
+}
+
+var networkNodeAttributes = []string{"car", "type", "file", "fanIn", "fanOut"}
+var networkEdgeAttributes = []string{"artifactLinks", "extractionMode"}
+
+func networkAttributeType(name string) string {
+	switch name {
+	case "fanIn", "fanOut", "artifactLinks":
+		return "int"
+	}
+	return "string"
+}
+
+func (n *networkNode) attributes() []string {
+	return []string{n.car, n.nodeType, n.file, strconv.Itoa(n.fanIn), strconv.Itoa(n.fanOut)}
+}
+
+func (e *networkEdge) attributes() []string {
+	return []string{strconv.Itoa(e.artifactLinks), e.extractionMode}
+}
+
+func (nw *network) addEdge(from *networkNode, to *networkNode, artifactLinks int, extractionMode string) {
+	nw.edges = append(nw.edges, &networkEdge{
+		id:             "edge" + strconv.Itoa(len(nw.edges)),
+		from:           from,
+		to:             to,
+		artifactLinks:  artifactLinks,
+		extractionMode: extractionMode,
+	})
+	from.fanOut++
+	to.fanIn++
+}
+
+func countArtifactLinks(dependency *CarDependency) int {
+	count := 0
+	for _, toArtifacts := range dependency.ArtifactDependencies {
+		count += len(toArtifacts)
+	}
+	return count
+}
+
+func newCarsNetwork(dependenciesMap *map[string]map[string]*CarDependency, carPaths map[string]string, isCarAllowed func(carName string) bool, findType string) *network {
+	nw := &network{}
+	nodeMap := map[string]*networkNode{}
+	for _, carName := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		if isCarAllowed(carName) {
+			nodeMap[carName] = &networkNode{id: "car" + strconv.Itoa(len(nw.nodes)), label: carName, car: carName, nodeType: "car", file: carPaths[carName]}
+			nw.nodes = append(nw.nodes, nodeMap[carName])
+		}
+	}
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			if carFrom != carTo && dependency.HaveDependency && nodeMap[carFrom] != nil && nodeMap[carTo] != nil {
+				nw.addEdge(nodeMap[carFrom], nodeMap[carTo], countArtifactLinks(dependency), findType)
+			}
+		}
+	}
+	return nw
+}
+
+// newArtifactsNetwork has the same artifacts as artifacts diagram, type and file are taken from parsed artifacts
+func newArtifactsNetwork(dependenciesMap *map[string]map[string]*CarDependency, artifactsMap *CarArtifacts, artifacts map[string]*Artifact, isCarAllowed func(carName string) bool, findType string) *network {
+	carArtifacts := getCarArtifactNames(dependenciesMap, artifactsMap)
+	nw := &network{}
+	nodeMap := map[string]map[string]*networkNode{}
+	for _, carName := range getSortedMapKeysFromArtifactFullMap(carArtifacts) {
+		if !isCarAllowed(carName) {
+			continue
+		}
+		nodeMap[carName] = map[string]*networkNode{}
+		for _, artifactName := range getSortedMapKeysFromArtifactsPartMap(carArtifacts[carName]) {
+			node := &networkNode{id: "artifact" + strconv.Itoa(len(nw.nodes)), label: artifactName, car: carName}
+			if artifact := artifacts[artifactName]; artifact != nil {
+				node.nodeType = artifact.Type
+				node.file = artifact.Path
+			}
+			nodeMap[carName][artifactName] = node
+			nw.nodes = append(nw.nodes, node)
+		}
+	}
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			for _, fromArtifact := range getSortedMapKeysFromArtifactFullMap(dependency.ArtifactDependencies) {
+				for _, toArtifact := range getSortedMapKeysFromArtifactsPartMap(dependency.ArtifactDependencies[fromArtifact]) {
+					fromNode, toNode := nodeMap[carFrom][fromArtifact], nodeMap[carTo][toArtifact]
+					if fromNode != nil && toNode != nil {
+						nw.addEdge(fromNode, toNode, 1, findType)
+					}
+				}
+			}
+		}
+	}
+	return nw
+}
+
+type graphMl struct {
+	XMLName xml.Name      `xml:"graphml"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Keys    []*graphMlKey `xml:"key"`
+	Graph   *graphMlGraph `xml:"graph"`
+}
+
+type graphMlKey struct {
+	Id       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMlGraph struct {
+	Id          string         `xml:"id,attr"`
+	EdgeDefault string         `xml:"edgedefault,attr"`
+	Nodes       []*graphMlNode `xml:"node"`
+	Edges       []*graphMlEdge `xml:"edge"`
+}
+
+type graphMlNode struct {
+	Id   string         `xml:"id,attr"`
+	Data []*graphMlData `xml:"data"`
+}
+
+type graphMlEdge struct {
+	Id     string         `xml:"id,attr"`
+	Source string         `xml:"source,attr"`
+	Target string         `xml:"target,attr"`
+	Data   []*graphMlData `xml:"data"`
+}
+
+type graphMlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// newGraphMl writes label as node data, yEd shows it with properties mapper
+func newGraphMl(nw *network) *graphMl {
+	g := &graphMl{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  []*graphMlKey{{Id: "label", For: "node", AttrName: "label", AttrType: "string"}},
+		Graph: &graphMlGraph{Id: "G", EdgeDefault: "directed"},
+	}
+	for _, name := range networkNodeAttributes {
+		g.Keys = append(g.Keys, &graphMlKey{Id: name, For: "node", AttrName: name, AttrType: networkAttributeType(name)})
+	}
+	for _, name := range networkEdgeAttributes {
+		g.Keys = append(g.Keys, &graphMlKey{Id: name, For: "edge", AttrName: name, AttrType: networkAttributeType(name)})
+	}
+	for _, node := range nw.nodes {
+		gNode := &graphMlNode{Id: node.id, Data: []*graphMlData{{Key: "label", Value: node.label}}}
+		for i, value := range node.attributes() {
+			gNode.Data = append(gNode.Data, &graphMlData{Key: networkNodeAttributes[i], Value: value})
+		}
+		g.Graph.Nodes = append(g.Graph.Nodes, gNode)
+	}
+	for _, edge := range nw.edges {
+		gEdge := &graphMlEdge{Id: edge.id, Source: edge.from.id, Target: edge.to.id}
+		for i, value := range edge.attributes() {
+			gEdge.Data = append(gEdge.Data, &graphMlData{Key: networkEdgeAttributes[i], Value: value})
+		}
+		g.Graph.Edges = append(g.Graph.Edges, gEdge)
+	}
+	return g
+}
+
+type gexf struct {
+	XMLName xml.Name   `xml:"gexf"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Version string     `xml:"version,attr"`
+	Graph   *gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	Mode            string            `xml:"mode,attr"`
+	DefaultEdgeType string            `xml:"defaultedgetype,attr"`
+	Attributes      []*gexfAttributes `xml:"attributes"`
+	Nodes           []*gexfNode       `xml:"nodes>node"`
+	Edges           []*gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string           `xml:"class,attr"`
+	Attributes []*gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	Id    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	Id        string          `xml:"id,attr"`
+	Label     string          `xml:"label,attr"`
+	AttValues []*gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	Id        string          `xml:"id,attr"`
+	Source    string          `xml:"source,attr"`
+	Target    string          `xml:"target,attr"`
+	Weight    int             `xml:"weight,attr"`
+	AttValues []*gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// newGexf uses number of artifact links as edge weight, Gephi scales edges by it
+func newGexf(nw *network) *gexf {
+	gexfAttributesOf := func(class string, names []string) *gexfAttributes {
+		attributes := &gexfAttributes{Class: class}
+		for _, name := range names {
+			attributes.Attributes = append(attributes.Attributes, &gexfAttribute{Id: name, Title: name, Type: gexfAttributeType(networkAttributeType(name))})
+		}
+		return attributes
+	}
+
+	g := &gexf{
+		Xmlns:   "http://www.gexf.net/1.2draft",
+		Version: "1.2",
+		Graph: &gexfGraph{
+			Mode:            "static",
+			DefaultEdgeType: "directed",
+			Attributes:      []*gexfAttributes{gexfAttributesOf("node", networkNodeAttributes), gexfAttributesOf("edge", networkEdgeAttributes)},
+		},
+	}
+	for _, node := range nw.nodes {
+		gNode := &gexfNode{Id: node.id, Label: node.label}
+		for i, value := range node.attributes() {
+			gNode.AttValues = append(gNode.AttValues, &gexfAttValue{For: networkNodeAttributes[i], Value: value})
+		}
+		g.Graph.Nodes = append(g.Graph.Nodes, gNode)
+	}
+	for _, edge := range nw.edges {
+		gEdge := &gexfEdge{Id: edge.id, Source: edge.from.id, Target: edge.to.id, Weight: edge.artifactLinks}
+		for i, value := range edge.attributes() {
+			gEdge.AttValues = append(gEdge.AttValues, &gexfAttValue{For: networkEdgeAttributes[i], Value: value})
+		}
+		g.Graph.Edges = append(g.Graph.Edges, gEdge)
+	}
+	return g
+}
+
+func gexfAttributeType(attributeType string) string {
+	if attributeType == "int" {
+		return "integer"
+	}
+	return attributeType
+}
+
+// printNetworks writes network to basePath with extension of every network format listed in outputs
+func printNetworks(nw *network, basePath string, outputs []string) {
+	printNetwork := func(path string, document interface{}) {
+		f, err := os.Create(path)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		f.WriteString(xml.Header)
+		encoder := xml.NewEncoder(f)
+		encoder.Indent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			panic(err)
+		}
+		f.WriteString("\n")
+	}
+	for _, output := range outputs {
+		switch output {
+		case "graphml":
+			printNetwork(basePath+".graphml", newGraphMl(nw))
+		case "gexf":
+			printNetwork(basePath+".gexf", newGexf(nw))
+		}
+	}
+}
+
+// printNetworkGraphs writes both cars and artifacts networks, they are meant for analysis in graph tools rather than viewing
+func printNetworkGraphs(dependenciesMap *map[string]map[string]*CarDependency, artifactsMap *CarArtifacts, artifacts map[string]*Artifact, carPaths map[string]string, outPath string, carNames []string, ignoreCarRegex string, findType string, outputs []string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	printNetworks(newCarsNetwork(dependenciesMap, carPaths, isCarAllowed, findType), filepath.Join(outPath, findType+"-graph"), outputs)
+	printNetworks(newArtifactsNetwork(dependenciesMap, artifactsMap, artifacts, isCarAllowed, findType), filepath.Join(outPath, findType+"-artifacts-graph"), outputs)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// getDeploymentOrder returns groups of cars in order of deployment, dependencies first.
+// Cars with cyclic dependencies are placed into one group and have to be deployed together.
+// Among groups ready for deployment the one with the least car name goes first, so order is stable.
+func getDeploymentOrder(dependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool) [][]string {
+	components := findCarComponents(dependenciesMap, isCarAllowed)
+	componentOfCar := map[string]int{}
+	for i, component := range components {
+		for _, car := range component {
+			componentOfCar[car] = i
+		}
+	}
+
+	// count of not deployed components each component depends on
+	pendingDeps := make([]int, len(components))
+	dependents := make([]map[int]bool, len(components))
+	for i := range components {
+		dependents[i] = map[int]bool{}
+	}
+	for i, component := range components {
+		for _, carFrom := range component {
+			for carTo, dependency := range (*dependenciesMap)[carFrom] {
+				toComponent, allowed := componentOfCar[carTo]
+				if !allowed || toComponent == i || !dependency.HaveDependency || dependents[toComponent][i] {
+					continue
+				}
+				dependents[toComponent][i] = true
+				pendingDeps[i]++
+			}
+		}
+	}
+
+	var ready []int
+	for i := range components {
+		if pendingDeps[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	var order [][]string
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			return components[ready[i]][0] < components[ready[j]][0]
+		})
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, components[next])
+		for dependent := range dependents[next] {
+			pendingDeps[dependent]--
+			if pendingDeps[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return order
+}
+
+func printDeploymentOrder(dependenciesMap *map[string]map[string]*CarDependency, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string) {
+	f, err := os.Create(filepath.Join(outPath, fileNamePrefix+"order.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	writeDeploymentOrder(w, dependenciesMap, carNames, ignoreCarRegex)
+
+	w.Flush()
+}
+
+func writeDeploymentOrder(w *bufio.Writer, dependenciesMap *map[string]map[string]*CarDependency, carNames []string, ignoreCarRegex string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+
+	for i, group := range getDeploymentOrder(dependenciesMap, isCarAllowed) {
+		w.WriteString(strconv.Itoa(i+1) + ". " + strings.Join(group, ", "))
+		if len(group) > 1 {
+			w.WriteString(" (cyclic, deploy together)")
+		}
+		w.WriteString("\n")
+	}
+}
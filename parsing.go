@@ -2,7 +2,6 @@ package main
 
 import (
 	"github.com/beevik/etree"
-	"log"
 	"regexp"
 	"strings"
 )
@@ -10,38 +9,95 @@ import (
 func FindArtifactsInDoc(doc *etree.Document) []string {
 	var foundArtifacts []string
 
-	childElements := doc.ChildElements()
-	rootElementName := childElements[0].Tag
+	rootElementName := doc.Root().Tag
 	switch rootElementName {
-	case "proxy", "sequence", "template", "api":
+	case "proxy", "sequence", "template", "api", "endpoint", "localEntry":
 		foundArtifacts = append(foundArtifacts, *FindTemplates(doc)...)
 		foundArtifacts = append(foundArtifacts, *FindSequences(doc)...)
+		foundArtifacts = append(foundArtifacts, *FindEndpoints(doc)...)
 		foundArtifacts = append(foundArtifacts, *FindResources(doc)...)
 		foundArtifacts = append(foundArtifacts, *FindLocalEntriesUseInProperty(doc)...)
+		foundArtifacts = append(foundArtifacts, *FindMessageStores(doc)...)
 	case "task":
 		foundArtifacts = append(foundArtifacts, *FindSequenceInTask(doc)...)
-	default:
-		log.Print(rootElementName)
+	case "messageStore":
+		foundArtifacts = append(foundArtifacts, *FindArtifactsInMessageStore(doc)...)
+	case "messageProcessor":
+		foundArtifacts = append(foundArtifacts, *FindArtifactsInMessageProcessor(doc)...)
+	case "inboundEndpoint":
+		foundArtifacts = append(foundArtifacts, *FindArtifactsInInboundEndpoint(doc)...)
 	}
 	return foundArtifacts
 }
 
-var getPropertyFuncRegex = regexp.MustCompile("get-property\\('(.+?)'\\)")
+var getPropertyFuncRegex = regexp.MustCompile(`get-property\(\s*['"](.+?)['"]\s*(?:,\s*['"](.+?)['"]\s*)?\)`)
+
+var expressionAttrNames = []string{"expression", "source", "xpath"}
 
 func FindLocalEntriesUseInProperty(doc *etree.Document) *[]string {
 	var foundArtifacts []string
-	propertyElements := doc.FindElements("//property")
-	for _, element := range propertyElements {
-		expressionAttr := element.SelectAttr("expression")
-		if expressionAttr != nil {
-			expressionAttrValue := expressionAttr.Value
-			foundGetPropertyArgs := getPropertyFuncRegex.FindAllString(expressionAttrValue, -1)
-			foundArtifacts = append(foundArtifacts, foundGetPropertyArgs...)
+	for _, attrName := range expressionAttrNames {
+		elements := doc.FindElements("//*[@" + attrName + "]")
+		for _, element := range elements {
+			expressionAttrValue := element.SelectAttr(attrName).Value
+			foundArtifacts = append(foundArtifacts, *FindGetPropertyArtifacts(expressionAttrValue)...)
 		}
 	}
 	return &foundArtifacts
 }
 
+// FindGetPropertyNames returns names read by get-property('name'), they are either local entries
+// or message context properties, so they can't be reported as unresolved references
+func FindGetPropertyNames(doc *etree.Document) map[string]bool {
+	names := map[string]bool{}
+	for _, attrName := range expressionAttrNames {
+		elements := doc.FindElements("//*[@" + attrName + "]")
+		for _, element := range elements {
+			for _, match := range getPropertyFuncRegex.FindAllStringSubmatch(element.SelectAttr(attrName).Value, -1) {
+				if len(match[2]) == 0 {
+					names[match[1]] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+// FindGetPropertyArtifacts returns local entries read by get-property('name')
+// and registry resources read by get-property('registry', 'path').
+func FindGetPropertyArtifacts(expression string) *[]string {
+	var foundArtifacts []string
+	for _, match := range getPropertyFuncRegex.FindAllStringSubmatch(expression, -1) {
+		scopeOrName, key := match[1], match[2]
+		if len(key) == 0 {
+			foundArtifacts = append(foundArtifacts, scopeOrName)
+			continue
+		}
+		switch scopeOrName {
+		case "registry":
+			foundArtifacts = append(foundArtifacts, normalizeRegistryKey(key))
+		case "env", "system":
+			// environment variables and system properties are set on server, not by artifacts
+		case "default", "axis2", "axis2-client", "transport", "operation", "func", "trace":
+			// message context properties are set at runtime, not by artifacts
+		default:
+			// unknown scopes read no artifacts either, synapse returns nothing for them
+		}
+	}
+	return &foundArtifacts
+}
+
+// normalizeRegistryKey converts a registry key to the resource name built by ArtifactParser
+func normalizeRegistryKey(key string) string {
+	if strings.HasPrefix(key, "gov:") {
+		return strings.TrimLeft(strings.TrimPrefix(key, "gov:"), "/")
+	}
+	if strings.HasPrefix(key, "conf:") {
+		return "/_system/config/" + strings.TrimLeft(strings.TrimPrefix(key, "conf:"), "/")
+	}
+	return key
+}
+
 func FindResources(doc *etree.Document) *[]string {
 	var foundArtifacts []string
 	resourcesElements := doc.FindElements("//schema")
@@ -51,9 +107,7 @@ func FindResources(doc *etree.Document) *[]string {
 	for _, element := range resourcesElements {
 		targetAttr := element.SelectAttr("key")
 		if targetAttr != nil {
-			targetAttrValue := targetAttr.Value
-			targetAttrValue = strings.TrimPrefix(targetAttrValue, "gov:")
-			foundArtifacts = append(foundArtifacts, targetAttrValue)
+			foundArtifacts = append(foundArtifacts, normalizeRegistryKey(targetAttr.Value))
 		}
 	}
 	return &foundArtifacts
@@ -62,6 +116,9 @@ func FindResources(doc *etree.Document) *[]string {
 func FindSequenceInTask(doc *etree.Document) *[]string {
 	var foundArtifacts []string
 	propertyElement := doc.FindElement("//property[@name='sequenceName']")
+	if propertyElement == nil {
+		return &foundArtifacts
+	}
 	valueAttr := propertyElement.SelectAttr("value")
 	if valueAttr != nil {
 		foundArtifacts = append(foundArtifacts, valueAttr.Value)
@@ -78,6 +135,14 @@ func FindTemplates(doc *etree.Document) *[]string {
 			foundArtifacts = append(foundArtifacts, targetAttr.Value)
 		}
 	}
+	// endpoints created from endpoint templates
+	endpointElements := doc.FindElements("//endpoint")
+	for _, element := range endpointElements {
+		templateAttr := element.SelectAttr("template")
+		if templateAttr != nil {
+			foundArtifacts = append(foundArtifacts, templateAttr.Value)
+		}
+	}
 	return &foundArtifacts
 }
 
@@ -89,6 +154,116 @@ func FindSequences(doc *etree.Document) *[]string {
 		if keyAttr != nil {
 			foundArtifacts = append(foundArtifacts, keyAttr.Value)
 		}
+		onErrorAttr := element.SelectAttr("onError")
+		if onErrorAttr != nil {
+			foundArtifacts = append(foundArtifacts, onErrorAttr.Value)
+		}
+	}
+	// proxy targets and api resources reference sequences by attributes
+	targetElements := doc.FindElements("//target")
+	attrElements := append(targetElements, doc.FindElements("//resource")...)
+	for _, element := range attrElements {
+		for _, attrName := range sequenceAttrNames {
+			attr := element.SelectAttr(attrName)
+			if attr != nil {
+				foundArtifacts = append(foundArtifacts, attr.Value)
+			}
+		}
+	}
+	// clone and iterate targets
+	for _, element := range targetElements {
+		sequenceAttr := element.SelectAttr("sequence")
+		if sequenceAttr != nil {
+			foundArtifacts = append(foundArtifacts, sequenceAttr.Value)
+		}
+	}
+	return &foundArtifacts
+}
+
+var sequenceAttrNames = []string{"inSequence", "outSequence", "faultSequence"}
+
+func FindEndpoints(doc *etree.Document) *[]string {
+	var foundArtifacts []string
+	// covers send, call, proxy target and members of failover, loadbalance and recipientlist endpoints
+	elements := doc.FindElements("//endpoint")
+	for _, element := range elements {
+		keyAttr := element.SelectAttr("key")
+		if keyAttr != nil {
+			foundArtifacts = append(foundArtifacts, keyAttr.Value)
+		}
+	}
+	targetElements := doc.FindElements("//target")
+	for _, element := range targetElements {
+		endpointAttr := element.SelectAttr("endpoint")
+		if endpointAttr != nil {
+			foundArtifacts = append(foundArtifacts, endpointAttr.Value)
+		}
+	}
+	return &foundArtifacts
+}
+
+// FindMessageStores returns message stores used by store mediators
+func FindMessageStores(doc *etree.Document) *[]string {
+	var foundArtifacts []string
+	elements := doc.FindElements("//store")
+	for _, element := range elements {
+		storeAttr := element.SelectAttr("messageStore")
+		if storeAttr != nil {
+			foundArtifacts = append(foundArtifacts, storeAttr.Value)
+		}
+	}
+	return &foundArtifacts
+}
+
+var messageStoreParams = []string{"store.failover.message.store.name"}
+
+func FindArtifactsInMessageStore(doc *etree.Document) *[]string {
+	return FindParameterValues(doc, messageStoreParams)
+}
+
+var messageProcessorParams = []string{
+	"message.processor.reply.sequence",
+	"message.processor.fault.sequence",
+	"message.processor.deactivate.sequence",
+	"message.processor.failMessagesStore",
+	"target.endpoint",
+}
+
+func FindArtifactsInMessageProcessor(doc *etree.Document) *[]string {
+	var foundArtifacts []string
+	rootElement := doc.Root()
+	for _, attrName := range []string{"messageStore", "targetEndpoint"} {
+		attr := rootElement.SelectAttr(attrName)
+		if attr != nil {
+			foundArtifacts = append(foundArtifacts, attr.Value)
+		}
+	}
+	foundArtifacts = append(foundArtifacts, *FindParameterValues(doc, messageProcessorParams)...)
+	return &foundArtifacts
+}
+
+func FindArtifactsInInboundEndpoint(doc *etree.Document) *[]string {
+	var foundArtifacts []string
+	rootElement := doc.Root()
+	for _, attrName := range []string{"sequence", "onError"} {
+		attr := rootElement.SelectAttr(attrName)
+		if attr != nil {
+			foundArtifacts = append(foundArtifacts, attr.Value)
+		}
+	}
+	return &foundArtifacts
+}
+
+func FindParameterValues(doc *etree.Document, paramNames []string) *[]string {
+	var foundArtifacts []string
+	for _, paramName := range paramNames {
+		elements := doc.FindElements("//parameter[@name='" + paramName + "']")
+		for _, element := range elements {
+			value := strings.TrimSpace(element.Text())
+			if len(value) > 0 {
+				foundArtifacts = append(foundArtifacts, value)
+			}
+		}
 	}
 	return &foundArtifacts
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+)
+
+func parseTestDocument(t *testing.T, content string) *etree.Document {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(content); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+type extractorTest struct {
+	name     string
+	content  string
+	expected string
+}
+
+// runExtractorTests checks references found in every snippet, expected lists them comma separated in document order
+func runExtractorTests(t *testing.T, extractor func(*etree.Document) *[]string, tests []extractorTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found := extractor(parseTestDocument(t, test.content))
+			if result := strings.Join(*found, ","); result != test.expected {
+				t.Errorf("got %q, expected %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestFindEndpoints(t *testing.T) {
+	runExtractorTests(t, FindEndpoints, []extractorTest{
+		{"send", `<sequence name="S"><send><endpoint key="EpA"/></send></sequence>`, "EpA"},
+		{"call", `<sequence name="S"><call><endpoint key="EpA"/></call></sequence>`, "EpA"},
+		{"failover members", `<endpoint name="E"><failover><endpoint key="EpA"/><endpoint key="EpB"/></failover></endpoint>`, "EpA,EpB"},
+		{"inline endpoint", `<sequence name="S"><send><endpoint><address uri="http://localhost"/></endpoint></send></sequence>`, ""},
+		{"proxy target", `<proxy name="P"><target endpoint="EpA" inSequence="SeqA"/></proxy>`, "EpA"},
+		{"target without endpoint", `<proxy name="P"><target inSequence="SeqA"/></proxy>`, ""},
+	})
+}
+
+func TestFindSequences(t *testing.T) {
+	runExtractorTests(t, FindSequences, []extractorTest{
+		{"sequence key", `<sequence name="S"><sequence key="SeqA"/></sequence>`, "SeqA"},
+		{"onError", `<sequence name="S" onError="FaultSeq"><log/></sequence>`, "FaultSeq"},
+		{"proxy target", `<proxy name="P"><target inSequence="SeqIn" outSequence="SeqOut" faultSequence="SeqFault"/></proxy>`, "SeqIn,SeqOut,SeqFault"},
+		{"api resource", `<api name="A" context="/a"><resource methods="GET" inSequence="SeqIn" faultSequence="SeqFault"/></api>`, "SeqIn,SeqFault"},
+		{"inline sequences", `<proxy name="P"><target><inSequence><log/></inSequence></target></proxy>`, ""},
+	})
+}
+
+func TestFindTargetSequences(t *testing.T) {
+	runExtractorTests(t, FindSequences, []extractorTest{
+		{"clone", `<sequence name="S"><clone><target sequence="SeqA"/><target sequence="SeqB"/></clone></sequence>`, "SeqA,SeqB"},
+		{"iterate", `<sequence name="S"><iterate expression="//item"><target sequence="SeqA"/></iterate></sequence>`, "SeqA"},
+		{"inline target", `<sequence name="S"><clone><target><sequence><log/></sequence></target></clone></sequence>`, ""},
+	})
+}
+
+func TestFindMessageStores(t *testing.T) {
+	runExtractorTests(t, FindMessageStores, []extractorTest{
+		{"store", `<sequence name="S"><store messageStore="StoreA"/></sequence>`, "StoreA"},
+		{"store without attribute", `<sequence name="S"><store/></sequence>`, ""},
+	})
+}
+
+func TestFindArtifactsInMessageStore(t *testing.T) {
+	runExtractorTests(t, FindArtifactsInMessageStore, []extractorTest{
+		{"failover store", `<messageStore name="M"><parameter name="store.failover.message.store.name">StoreB</parameter></messageStore>`, "StoreB"},
+		{"in memory", `<messageStore name="M"/>`, ""},
+	})
+}
+
+func TestFindArtifactsInMessageProcessor(t *testing.T) {
+	runExtractorTests(t, FindArtifactsInMessageProcessor, []extractorTest{
+		{"store and endpoint", `<messageProcessor name="P" messageStore="StoreA" targetEndpoint="EpA"/>`, "StoreA,EpA"},
+		{"parameters", `<messageProcessor name="P" messageStore="StoreA">
+			<parameter name="message.processor.reply.sequence">SeqReply</parameter>
+			<parameter name="message.processor.fault.sequence"> SeqFault </parameter>
+			<parameter name="message.processor.deactivate.sequence">SeqOff</parameter>
+			<parameter name="message.processor.failMessagesStore">StoreFail</parameter>
+			<parameter name="interval">1000</parameter>
+		</messageProcessor>`, "StoreA,SeqReply,SeqFault,SeqOff,StoreFail"},
+	})
+}
+
+func TestFindArtifactsInInboundEndpoint(t *testing.T) {
+	runExtractorTests(t, FindArtifactsInInboundEndpoint, []extractorTest{
+		{"sequences", `<inboundEndpoint name="I" sequence="SeqA" onError="SeqFault" protocol="http"/>`, "SeqA,SeqFault"},
+		{"no sequences", `<inboundEndpoint name="I" protocol="http"/>`, ""},
+	})
+}
+
+func TestFindGetPropertyArtifacts(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{`get-property('LocalEntryA')`, "LocalEntryA"},
+		{`get-property("LocalEntryA")`, "LocalEntryA"},
+		{`get-property('registry', 'gov:/schemas/a.xsd')`, "schemas/a.xsd"},
+		{`get-property('registry', 'gov:schemas/a.xsd')`, "schemas/a.xsd"},
+		{`get-property('registry', 'conf:/props/a.xml')`, "/_system/config/props/a.xml"},
+		{`get-property('env', 'HOME')`, ""},
+		{`get-property('system', 'user.dir')`, ""},
+		{`get-property('default', 'To')`, ""},
+		{`get-property('axis2', 'HTTP_SC')`, ""},
+		{`get-property('transport', 'Content-Type')`, ""},
+		{`get-property('operation', 'count')`, ""},
+		{`get-property('unknown', 'a')`, ""},
+		{`concat(get-property('A'), get-property( 'axis2' , 'B' ), get-property('C'))`, "A,C"},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			if result := strings.Join(*FindGetPropertyArtifacts(test.expression), ","); result != test.expected {
+				t.Errorf("got %q, expected %q", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestFindLocalEntriesUseInProperty(t *testing.T) {
+	runExtractorTests(t, FindLocalEntriesUseInProperty, []extractorTest{
+		{"property expression", `<sequence name="S"><property name="p" expression="get-property('LocalEntryA')"/></sequence>`, "LocalEntryA"},
+		{"filter source", `<sequence name="S"><filter source="get-property('registry', 'gov:a.xml')" regex="x"/></sequence>`, "a.xml"},
+		{"message property", `<sequence name="S"><property name="p" expression="get-property('transport', 'Host')"/></sequence>`, ""},
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+// runWorkers calls work for every path sent by produce in parallelism goroutines and returns when all paths are processed.
+// produce blocks while all workers are busy, so paths are not accumulated in memory.
+func runWorkers(parallelism int, produce func(paths chan<- string), work func(path string)) {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	paths := make(chan string, parallelism)
+	var group sync.WaitGroup
+	group.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			defer group.Done()
+			for path := range paths {
+				work(path)
+			}
+		}()
+	}
+	produce(paths)
+	close(paths)
+	group.Wait()
+}
@@ -13,12 +13,18 @@ import (
 )
 
 func printGraph(dependenciesMap *map[string]map[string]*CarDependency, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string) {
-	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
-
 	f, _ := os.Create(filepath.Join(outPath, fileNamePrefix+"graph.txt"))
 	defer f.Close()
 	w := bufio.NewWriter(f)
 
+	writeGraph(w, dependenciesMap, carNames, ignoreCarRegex)
+
+	w.Flush()
+}
+
+func writeGraph(w *bufio.Writer, dependenciesMap *map[string]map[string]*CarDependency, carNames []string, ignoreCarRegex string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+
 	keys := getSortedMapKeysFromFullDepsMap(dependenciesMap)
 
 	for _, carFrom := range keys {
@@ -48,8 +54,6 @@ func printGraph(dependenciesMap *map[string]map[string]*CarDependency, outPath s
 			w.WriteString("\n")
 		}
 	}
-
-	w.Flush()
 }
 
 func getSortedMapKeysFromArtifactsPartMap(m map[string]bool) []string {
@@ -63,6 +67,28 @@ func getSortedMapKeysFromArtifactsPartMap(m map[string]bool) []string {
 	return keys
 }
 
+func getSortedMapKeysFromCarArtifacts(m *CarArtifacts) []string {
+	keys := make([]string, len(*m))
+	i := 0
+	for k := range *m {
+		keys[i] = k
+		i++
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getSortedMapKeysFromStringMap(m map[string]string) []string {
+	keys := make([]string, len(m))
+	i := 0
+	for k := range m {
+		keys[i] = k
+		i++
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func getSortedMapKeysFromArtifactFullMap(m map[string]map[string]bool) []string {
 	keys := make([]string, len(m))
 	i := 0
@@ -106,7 +132,7 @@ func calcMaxFromArtifactDepLen(dependency *CarDependency) int {
 	return maxArtifactLen
 }
 
-func renderGraph(dependenciesMap *map[string]map[string]*CarDependency, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string) {
+func renderGraph(dependenciesMap *map[string]map[string]*CarDependency, links *graphLinks, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string, outputs []string) {
 	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
 
 	g := graphviz.New()
@@ -121,6 +147,15 @@ func renderGraph(dependenciesMap *map[string]map[string]*CarDependency, outPath
 		g.Close()
 	}()
 
+	addCarsToGraph(graph, dependenciesMap, isCarAllowed, links)
+	renderGraphFiles(g, graph, filepath.Join(outPath, fileNamePrefix+"graph"), outputs)
+}
+
+// addCarsToGraph adds allowed cars and dependencies between them to graph, edges closing cycles are red.
+// Car nodes link to car paths unless links are nil.
+func addCarsToGraph(graph *cgraph.Graph, dependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool, links *graphLinks) {
+	cycleEdges := getCycleEdges(dependenciesMap, findCarCycles(dependenciesMap, isCarAllowed))
+
 	nodeMap := map[string]*cgraph.Node{}
 	appendNodeToGraph := func(carName string) {
 		if nodeMap[carName] == nil {
@@ -128,6 +163,10 @@ func renderGraph(dependenciesMap *map[string]map[string]*CarDependency, outPath
 			if err != nil {
 				panic(err)
 			}
+			if carUrl := links.url(links.carPath(carName)); len(carUrl) > 0 {
+				n1.SetURL(carUrl)
+				n1.SetTooltip(links.carPath(carName))
+			}
 			nodeMap[carName] = n1
 		}
 	}
@@ -143,25 +182,43 @@ func renderGraph(dependenciesMap *map[string]map[string]*CarDependency, outPath
 			}
 
 			if carFrom != carTo && dependency.HaveDependency && nodeMap[carFrom] != nil && nodeMap[carTo] != nil {
-				_, err := graph.CreateEdge("", nodeMap[carFrom], nodeMap[carTo])
+				edge, err := graph.CreateEdge("", nodeMap[carFrom], nodeMap[carTo])
 				if err != nil {
 					log.Fatal(err)
 				}
+				if cycleEdges[carFrom][carTo] {
+					edge.SetColor("red")
+				}
 			}
 		}
 	}
+}
 
-	if err := g.RenderFilename(graph, graphviz.PNG, filepath.Join(outPath, fileNamePrefix+"graph.png")); err != nil {
-		panic(err)
-	}
-
-	f, err := os.Create(filepath.Join(outPath, fileNamePrefix+"graph.dot"))
-	defer f.Close()
-	w := bufio.NewWriter(f)
-	if err := g.Render(graph, graphviz.XDOT, w); err != nil {
-		panic(err)
+// renderGraphFiles writes graph to basePath with extension of every graphviz format listed in outputs
+func renderGraphFiles(g *graphviz.Graphviz, graph *cgraph.Graph, basePath string, outputs []string) {
+	for _, output := range outputs {
+		switch output {
+		case "png":
+			if err := g.RenderFilename(graph, graphviz.PNG, basePath+".png"); err != nil {
+				panic(err)
+			}
+		case "svg":
+			if err := g.RenderFilename(graph, graphviz.SVG, basePath+".svg"); err != nil {
+				panic(err)
+			}
+		case "dot":
+			f, err := os.Create(basePath + ".dot")
+			if err != nil {
+				panic(err)
+			}
+			w := bufio.NewWriter(f)
+			if err := g.Render(graph, graphviz.XDOT, w); err != nil {
+				panic(err)
+			}
+			w.Flush()
+			f.Close()
+		}
 	}
-	w.Flush()
 }
 
 func createIsCarAllowedFunc(carNames []string, ignoreCarRegex string) func(carName string) bool {
@@ -189,7 +246,7 @@ func createIsCarAllowedFunc(carNames []string, ignoreCarRegex string) func(carNa
 
 func renderBothTypesGraph(dependenciesMapRegex *map[string]map[string]*CarDependency,
 	dependenciesMapMarshalling *map[string]map[string]*CarDependency,
-	outPath string, carNames []string, ignoreCarRegex string) {
+	outPath string, carNames []string, ignoreCarRegex string, outputs []string) {
 	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
 
 	g := graphviz.New()
@@ -251,15 +308,5 @@ func renderBothTypesGraph(dependenciesMapRegex *map[string]map[string]*CarDepend
 	appendEdges(dependenciesMapRegex, "blue", "red")
 	appendEdges(dependenciesMapMarshalling, "green", "red")
 
-	if err := g.RenderFilename(graph, graphviz.PNG, filepath.Join(outPath, "both-graph.png")); err != nil {
-		panic(err)
-	}
-
-	f, err := os.Create(filepath.Join(outPath, "both-graph.dot"))
-	defer f.Close()
-	w := bufio.NewWriter(f)
-	if err := g.Render(graph, graphviz.XDOT, w); err != nil {
-		panic(err)
-	}
-	w.Flush()
+	renderGraphFiles(g, graph, filepath.Join(outPath, "both-graph"), outputs)
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"github.com/goccy/go-graphviz"
+	"github.com/goccy/go-graphviz/cgraph"
+	"log"
+	"path/filepath"
+)
+
+type artifactStyle struct {
+	shape cgraph.Shape
+	color string
+}
+
+var defaultArtifactStyle = artifactStyle{cgraph.EllipseShape, "white"}
+
+var artifactTypeStyles = map[string]artifactStyle{
+	"synapse/api":                {cgraph.ComponentShape, "lightskyblue"},
+	"synapse/endpoint":           {cgraph.HexagonShape, "orange"},
+	"synapse/endpointTemplate":   {cgraph.HexagonShape, "khaki"},
+	"synapse/inbound-endpoint":   {cgraph.ParallelogramShape, "lightcyan"},
+	"synapse/local-entry":        {cgraph.NoteShape, "lightyellow"},
+	"synapse/message-processors": {cgraph.OctagonShape, "pink"},
+	"synapse/message-store":      {cgraph.CylinderShape, "lightgrey"},
+	"synapse/proxy-service":      {cgraph.ComponentShape, "lightblue"},
+	"synapse/sequence":           {cgraph.BoxShape, "palegreen"},
+	"synapse/sequenceTemplate":   {cgraph.BoxShape, "khaki"},
+	"synapse/task":               {cgraph.DiamondShape, "plum"},
+	"synapse/template":           {cgraph.BoxShape, "khaki"},
+	"registry/resource":          {cgraph.FolderShape, "wheat"},
+}
+
+// renderArtifactsGraph draws every artifact of allowed cars as a node inside a cluster of its car,
+// artifact nodes link to files defining them
+func renderArtifactsGraph(dependenciesMap *map[string]map[string]*CarDependency, artifactsMap *CarArtifacts, artifacts map[string]*Artifact, links *graphLinks, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string, outputs []string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+
+	g := graphviz.New()
+	graph, err := g.Graph()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		if err := graph.Close(); err != nil {
+			log.Fatal(err)
+		}
+		g.Close()
+	}()
+
+	clusterMap := map[string]*cgraph.Graph{}
+	nodeMap := map[string]map[string]*cgraph.Node{}
+	appendNodeToGraph := func(carName string, artifactName string) {
+		if !isCarAllowed(carName) || nodeMap[carName][artifactName] != nil {
+			return
+		}
+		if clusterMap[carName] == nil {
+			clusterMap[carName] = graph.SubGraph("cluster_"+carName, 1)
+			clusterMap[carName].SetLabel(carName)
+			nodeMap[carName] = map[string]*cgraph.Node{}
+		}
+		node, err := clusterMap[carName].CreateNode(carName + "/" + artifactName)
+		if err != nil {
+			panic(err)
+		}
+		style := defaultArtifactStyle
+		if artifact := artifacts[artifactName]; artifact != nil {
+			if typeStyle, ok := artifactTypeStyles[artifact.Type]; ok {
+				style = typeStyle
+			}
+		}
+		node.SetLabel(artifactName)
+		node.SetShape(style.shape)
+		node.SetStyle(cgraph.FilledNodeStyle)
+		node.SetFillColor(style.color)
+		if artifactUrl := links.url(links.artifactPath(artifactName)); len(artifactUrl) > 0 {
+			node.SetURL(artifactUrl)
+			node.SetTooltip(links.artifactPath(artifactName))
+		}
+		nodeMap[carName][artifactName] = node
+	}
+
+	for _, carName := range getSortedMapKeysFromCarArtifacts(artifactsMap) {
+		for _, artifact := range (*artifactsMap)[carName] {
+			appendNodeToGraph(carName, artifact.Name)
+		}
+	}
+
+	for _, carFrom := range getSortedMapKeysFromFullDepsMap(dependenciesMap) {
+		for _, carTo := range getSortedMapKeyFromPartDepsMap((*dependenciesMap)[carFrom]) {
+			dependency := (*dependenciesMap)[carFrom][carTo]
+			for _, fromArtifact := range getSortedMapKeysFromArtifactFullMap(dependency.ArtifactDependencies) {
+				appendNodeToGraph(carFrom, fromArtifact)
+				for _, toArtifact := range getSortedMapKeysFromArtifactsPartMap(dependency.ArtifactDependencies[fromArtifact]) {
+					appendNodeToGraph(carTo, toArtifact)
+					fromNode, toNode := nodeMap[carFrom][fromArtifact], nodeMap[carTo][toArtifact]
+					if fromNode != nil && toNode != nil {
+						if _, err := graph.CreateEdge("", fromNode, toNode); err != nil {
+							log.Fatal(err)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	renderGraphFiles(g, graph, filepath.Join(outPath, fileNamePrefix+"artifacts-graph"), outputs)
+}
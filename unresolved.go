@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// synapse artifacts existing on every server
+var builtInArtifacts = map[string]bool{
+	"main":  true,
+	"fault": true,
+}
+
+// registry paths of server system resources, after gov: and conf: prefixes normalization
+var builtInRegistryPrefixes = []string{
+	"repository/",
+	"/_system/config/repository/",
+	"/_system/governance/repository/",
+	"/_system/local/",
+}
+
+// UnresolvedReference is a reference to an artifact absent in all analysed cars
+type UnresolvedReference struct {
+	Car       string
+	Artifact  string
+	Path      string
+	Reference string
+	BuiltIn   bool
+}
+
+func isBuiltInArtifact(name string) bool {
+	if builtInArtifacts[name] {
+		return true
+	}
+	for _, prefix := range builtInRegistryPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDynamicKey checks key evaluated at runtime like {get-property('name')} or {$ctx:name}, it can't be resolved statically
+func isDynamicKey(key string) bool {
+	return strings.HasPrefix(key, "{") && strings.HasSuffix(key, "}")
+}
+
+func (d *DepsParser) addUnresolvedReference(reference string, carName string, fromArtifact string, fromPath string) {
+	key := carName + "\x00" + fromPath + "\x00" + reference
+	if d.unresolved[key] == nil {
+		d.unresolved[key] = &UnresolvedReference{
+			Car:       carName,
+			Artifact:  fromArtifact,
+			Path:      fromPath,
+			Reference: reference,
+			BuiltIn:   isBuiltInArtifact(reference),
+		}
+	}
+}
+
+// getUnresolvedReferences returns unresolved references of allowed cars sorted by car, file and reference
+func (d *DepsParser) getUnresolvedReferences(isCarAllowed func(carName string) bool) []*UnresolvedReference {
+	var references []*UnresolvedReference
+	for _, reference := range d.unresolved {
+		if isCarAllowed(reference.Car) {
+			references = append(references, reference)
+		}
+	}
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].Car != references[j].Car {
+			return references[i].Car < references[j].Car
+		}
+		if references[i].Path != references[j].Path {
+			return references[i].Path < references[j].Path
+		}
+		return references[i].Reference < references[j].Reference
+	})
+	return references
+}
+
+func countMissingReferences(references []*UnresolvedReference) int {
+	count := 0
+	for _, reference := range references {
+		if !reference.BuiltIn {
+			count++
+		}
+	}
+	return count
+}
+
+func printUnresolvedReferences(references []*UnresolvedReference, outPath string, fileNamePrefix string) {
+	f, err := os.Create(filepath.Join(outPath, fileNamePrefix+"unresolved.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	var lastCar, lastPath string
+	for _, reference := range references {
+		if reference.Car != lastCar {
+			if len(lastCar) > 0 {
+				w.WriteString("\n")
+			}
+			w.WriteString(reference.Car + "\n")
+			lastCar = reference.Car
+			lastPath = ""
+		}
+		if reference.Path != lastPath {
+			w.WriteString("  " + reference.Artifact + " (" + reference.Path + ")\n")
+			lastPath = reference.Path
+		}
+		kind := "missing "
+		if reference.BuiltIn {
+			kind = "built-in"
+		}
+		w.WriteString("    " + kind + " " + reference.Reference + "\n")
+	}
+
+	w.Flush()
+}
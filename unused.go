@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// artifact types started by server itself, they are not referenced by other artifacts
+var entryPointTypes = map[string]bool{
+	"synapse/api":                true,
+	"synapse/inbound-endpoint":   true,
+	"synapse/message-processors": true,
+	"synapse/proxy-service":      true,
+	"synapse/task":               true,
+}
+
+// findUnusedArtifacts returns artifacts of allowed cars not referenced by any other artifact, except entry points.
+// Artifacts of every car are sorted by type and name.
+func findUnusedArtifacts(artifactsMap *CarArtifacts, dependenciesMap *map[string]map[string]*CarDependency, isCarAllowed func(carName string) bool) map[string][]*Artifact {
+	referenced := map[string]bool{}
+	for _, depToCars := range *dependenciesMap {
+		for _, dependency := range depToCars {
+			for _, toArtifacts := range dependency.ArtifactDependencies {
+				for toArtifact := range toArtifacts {
+					referenced[toArtifact] = true
+				}
+			}
+		}
+	}
+
+	unused := map[string][]*Artifact{}
+	for carName, artifacts := range *artifactsMap {
+		if !isCarAllowed(carName) {
+			continue
+		}
+		for _, artifact := range artifacts {
+			if referenced[artifact.Name] || entryPointTypes[artifact.Type] || builtInArtifacts[artifact.Name] {
+				continue
+			}
+			unused[carName] = append(unused[carName], artifact)
+		}
+		sort.Slice(unused[carName], func(i, j int) bool {
+			if unused[carName][i].Type != unused[carName][j].Type {
+				return unused[carName][i].Type < unused[carName][j].Type
+			}
+			return unused[carName][i].Name < unused[carName][j].Name
+		})
+	}
+	return unused
+}
+
+func printUnusedArtifacts(artifactsMap *CarArtifacts, dependenciesMap *map[string]map[string]*CarDependency, outPath string, carNames []string, ignoreCarRegex string, fileNamePrefix string) {
+	f, err := os.Create(filepath.Join(outPath, fileNamePrefix+"unused.txt"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	writeUnusedArtifacts(w, artifactsMap, dependenciesMap, carNames, ignoreCarRegex)
+
+	w.Flush()
+}
+
+func writeUnusedArtifacts(w *bufio.Writer, artifactsMap *CarArtifacts, dependenciesMap *map[string]map[string]*CarDependency, carNames []string, ignoreCarRegex string) {
+	isCarAllowed := createIsCarAllowedFunc(carNames, ignoreCarRegex)
+	unused := findUnusedArtifacts(artifactsMap, dependenciesMap, isCarAllowed)
+
+	carNamesSorted := make([]string, 0, len(unused))
+	for carName := range unused {
+		carNamesSorted = append(carNamesSorted, carName)
+	}
+	sort.Strings(carNamesSorted)
+	for _, carName := range carNamesSorted {
+		w.WriteString(carName + "\n")
+		lastType := "\x00"
+		for _, artifact := range unused[carName] {
+			if artifact.Type != lastType {
+				w.WriteString("  " + artifact.Type + "\n")
+				lastType = artifact.Type
+			}
+			w.WriteString("    " + artifact.Name + "\n")
+		}
+		w.WriteString("\n")
+	}
+}
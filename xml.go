@@ -7,7 +7,11 @@ type Artifacts struct {
 type Artifact struct {
 	Name string `xml:"name,attr"`
 	Type string `xml:"type,attr"`
+	File string `xml:"file"`
 	Item Item   `xml:"item"`
+
+	// Path is the file defining the artifact, inside a .car archive separated by "!/"
+	Path string `xml:"-"`
 }
 
 type Item struct {